@@ -0,0 +1,1015 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// This file hand-rolls JSON marshalling for the Expression and Statement
+// interfaces declared in the autogenerated expression.go/statement.go.
+// Each node is written as {"kind": "<GoTypeName>", ...fields}, and
+// UnmarshalExpression/UnmarshalStatement read the "kind" tag back to
+// reconstruct the right concrete type. This is what backs `--emit-ast` and
+// `--load-ast`, so external tools can produce or consume a Lox AST without
+// re-implementing the scanner and parser.
+
+func marshalExpr(expression Expression) (json.RawMessage, error) {
+	if expression == nil {
+		return json.RawMessage("null"), nil
+	}
+	bytes, err := MarshalExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(bytes), nil
+}
+
+func marshalExprList(expressions []Expression) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, len(expressions))
+	for idx, expression := range expressions {
+		raw, err := marshalExpr(expression)
+		if err != nil {
+			return nil, err
+		}
+		out[idx] = raw
+	}
+	return out, nil
+}
+
+func marshalStmt(statement Statement) (json.RawMessage, error) {
+	if statement == nil {
+		return json.RawMessage("null"), nil
+	}
+	bytes, err := MarshalStatement(statement)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(bytes), nil
+}
+
+func marshalStmtList(statements []Statement) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, len(statements))
+	for idx, statement := range statements {
+		raw, err := marshalStmt(statement)
+		if err != nil {
+			return nil, err
+		}
+		out[idx] = raw
+	}
+	return out, nil
+}
+
+func isNullRaw(data json.RawMessage) bool {
+	return data == nil || string(data) == "null"
+}
+
+// --- Expression ---
+
+type assignExpressionJSON struct {
+	Kind  string          `json:"kind"`
+	Name  *Token          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+type binaryExpressionJSON struct {
+	Kind     string          `json:"kind"`
+	Left     json.RawMessage `json:"left"`
+	Operator *Token          `json:"operator"`
+	Right    json.RawMessage `json:"right"`
+}
+
+type callExpressionJSON struct {
+	Kind      string            `json:"kind"`
+	Callee    json.RawMessage   `json:"callee"`
+	Paren     *Token            `json:"paren"`
+	Arguments []json.RawMessage `json:"arguments"`
+}
+
+type getExpressionJSON struct {
+	Kind   string          `json:"kind"`
+	Object json.RawMessage `json:"object"`
+	Name   *Token          `json:"name"`
+}
+
+type groupingExpressionJSON struct {
+	Kind       string          `json:"kind"`
+	Expression json.RawMessage `json:"expression"`
+}
+
+type literalExpressionJSON struct {
+	Kind  string       `json:"kind"`
+	Value LiteralValue `json:"value"`
+}
+
+type logicalExpressionJSON struct {
+	Kind     string          `json:"kind"`
+	Left     json.RawMessage `json:"left"`
+	Operator *Token          `json:"operator"`
+	Right    json.RawMessage `json:"right"`
+}
+
+type setExpressionJSON struct {
+	Kind   string          `json:"kind"`
+	Object json.RawMessage `json:"object"`
+	Name   *Token          `json:"name"`
+	Value  json.RawMessage `json:"value"`
+}
+
+type superExpressionJSON struct {
+	Kind    string `json:"kind"`
+	Keyword *Token `json:"keyword"`
+	Method  *Token `json:"method"`
+}
+
+type thisExpressionJSON struct {
+	Kind    string `json:"kind"`
+	Keyword *Token `json:"keyword"`
+}
+
+type ternaryExpressionJSON struct {
+	Kind      string          `json:"kind"`
+	Condition json.RawMessage `json:"condition"`
+	True      json.RawMessage `json:"true"`
+	False     json.RawMessage `json:"false"`
+}
+
+type unaryExpressionJSON struct {
+	Kind     string          `json:"kind"`
+	Operator *Token          `json:"operator"`
+	Right    json.RawMessage `json:"right"`
+}
+
+type variableExpressionJSON struct {
+	Kind string `json:"kind"`
+	Name *Token `json:"name"`
+}
+
+type listExpressionJSON struct {
+	Kind     string            `json:"kind"`
+	Bracket  *Token            `json:"bracket"`
+	Elements []json.RawMessage `json:"elements"`
+}
+
+type mapExpressionJSON struct {
+	Kind   string            `json:"kind"`
+	Brace  *Token            `json:"brace"`
+	Keys   []json.RawMessage `json:"keys"`
+	Values []json.RawMessage `json:"values"`
+}
+
+type indexExpressionJSON struct {
+	Kind    string          `json:"kind"`
+	Object  json.RawMessage `json:"object"`
+	Bracket *Token          `json:"bracket"`
+	Index   json.RawMessage `json:"index"`
+}
+
+type indexSetExpressionJSON struct {
+	Kind    string          `json:"kind"`
+	Object  json.RawMessage `json:"object"`
+	Bracket *Token          `json:"bracket"`
+	Index   json.RawMessage `json:"index"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// MarshalExpression encodes an Expression node (and, recursively, its
+// children) tagged with its concrete Go type name as "kind".
+func MarshalExpression(expression Expression) ([]byte, error) {
+	switch e := expression.(type) {
+	case *AssignExpression:
+		value, err := marshalExpr(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(assignExpressionJSON{Kind: "AssignExpression", Name: e.Name, Value: value})
+
+	case *BinaryExpression:
+		left, err := marshalExpr(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := marshalExpr(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(binaryExpressionJSON{Kind: "BinaryExpression", Left: left, Operator: e.Operator, Right: right})
+
+	case *CallExpression:
+		callee, err := marshalExpr(e.Callee)
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := marshalExprList(e.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(callExpressionJSON{Kind: "CallExpression", Callee: callee, Paren: e.Paren, Arguments: arguments})
+
+	case *GetExpression:
+		object, err := marshalExpr(e.Object)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(getExpressionJSON{Kind: "GetExpression", Object: object, Name: e.Name})
+
+	case *GroupingExpression:
+		inner, err := marshalExpr(e.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(groupingExpressionJSON{Kind: "GroupingExpression", Expression: inner})
+
+	case *LiteralExpression:
+		return json.Marshal(literalExpressionJSON{Kind: "LiteralExpression", Value: e.Value})
+
+	case *LogicalExpression:
+		left, err := marshalExpr(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := marshalExpr(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(logicalExpressionJSON{Kind: "LogicalExpression", Left: left, Operator: e.Operator, Right: right})
+
+	case *SetExpression:
+		object, err := marshalExpr(e.Object)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalExpr(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(setExpressionJSON{Kind: "SetExpression", Object: object, Name: e.Name, Value: value})
+
+	case *SuperExpression:
+		return json.Marshal(superExpressionJSON{Kind: "SuperExpression", Keyword: e.Keyword, Method: e.Method})
+
+	case *ThisExpression:
+		return json.Marshal(thisExpressionJSON{Kind: "ThisExpression", Keyword: e.Keyword})
+
+	case *TernaryExpression:
+		condition, err := marshalExpr(e.Condition)
+		if err != nil {
+			return nil, err
+		}
+		trueBranch, err := marshalExpr(e.True)
+		if err != nil {
+			return nil, err
+		}
+		falseBranch, err := marshalExpr(e.False)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(ternaryExpressionJSON{Kind: "TernaryExpression", Condition: condition, True: trueBranch, False: falseBranch})
+
+	case *UnaryExpression:
+		right, err := marshalExpr(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(unaryExpressionJSON{Kind: "UnaryExpression", Operator: e.Operator, Right: right})
+
+	case *VariableExpression:
+		return json.Marshal(variableExpressionJSON{Kind: "VariableExpression", Name: e.Name})
+
+	case *ListExpression:
+		elements, err := marshalExprList(e.Elements)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(listExpressionJSON{Kind: "ListExpression", Bracket: e.Bracket, Elements: elements})
+
+	case *MapExpression:
+		keys, err := marshalExprList(e.Keys)
+		if err != nil {
+			return nil, err
+		}
+		values, err := marshalExprList(e.Values)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(mapExpressionJSON{Kind: "MapExpression", Brace: e.Brace, Keys: keys, Values: values})
+
+	case *IndexExpression:
+		object, err := marshalExpr(e.Object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := marshalExpr(e.Index)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(indexExpressionJSON{Kind: "IndexExpression", Object: object, Bracket: e.Bracket, Index: index})
+
+	case *IndexSetExpression:
+		object, err := marshalExpr(e.Object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := marshalExpr(e.Index)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalExpr(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(indexSetExpressionJSON{Kind: "IndexSetExpression", Object: object, Bracket: e.Bracket, Index: index, Value: value})
+
+	default:
+		return nil, fmt.Errorf("unsupported expression type %T", expression)
+	}
+}
+
+// UnmarshalExpression reconstructs an Expression from its tagged JSON form.
+func UnmarshalExpression(data json.RawMessage) (Expression, error) {
+	if isNullRaw(data) {
+		return nil, nil
+	}
+
+	var probe struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	switch probe.Kind {
+	case "AssignExpression":
+		var wire assignExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		value, err := UnmarshalExpression(wire.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &AssignExpression{Name: wire.Name, Value: value}, nil
+
+	case "BinaryExpression":
+		var wire binaryExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		left, err := UnmarshalExpression(wire.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := UnmarshalExpression(wire.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpression{Left: left, Operator: wire.Operator, Right: right}, nil
+
+	case "CallExpression":
+		var wire callExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		callee, err := UnmarshalExpression(wire.Callee)
+		if err != nil {
+			return nil, err
+		}
+		arguments := make([]Expression, len(wire.Arguments))
+		for idx, raw := range wire.Arguments {
+			argument, err := UnmarshalExpression(raw)
+			if err != nil {
+				return nil, err
+			}
+			arguments[idx] = argument
+		}
+		return &CallExpression{Callee: callee, Paren: wire.Paren, Arguments: arguments}, nil
+
+	case "GetExpression":
+		var wire getExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		object, err := UnmarshalExpression(wire.Object)
+		if err != nil {
+			return nil, err
+		}
+		return &GetExpression{Object: object, Name: wire.Name}, nil
+
+	case "GroupingExpression":
+		var wire groupingExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		inner, err := UnmarshalExpression(wire.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &GroupingExpression{Expression: inner}, nil
+
+	case "LiteralExpression":
+		var wire literalExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		return &LiteralExpression{Value: wire.Value}, nil
+
+	case "LogicalExpression":
+		var wire logicalExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		left, err := UnmarshalExpression(wire.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := UnmarshalExpression(wire.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &LogicalExpression{Left: left, Operator: wire.Operator, Right: right}, nil
+
+	case "SetExpression":
+		var wire setExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		object, err := UnmarshalExpression(wire.Object)
+		if err != nil {
+			return nil, err
+		}
+		value, err := UnmarshalExpression(wire.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &SetExpression{Object: object, Name: wire.Name, Value: value}, nil
+
+	case "SuperExpression":
+		var wire superExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		return &SuperExpression{Keyword: wire.Keyword, Method: wire.Method}, nil
+
+	case "ThisExpression":
+		var wire thisExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		return &ThisExpression{Keyword: wire.Keyword}, nil
+
+	case "TernaryExpression":
+		var wire ternaryExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		condition, err := UnmarshalExpression(wire.Condition)
+		if err != nil {
+			return nil, err
+		}
+		trueBranch, err := UnmarshalExpression(wire.True)
+		if err != nil {
+			return nil, err
+		}
+		falseBranch, err := UnmarshalExpression(wire.False)
+		if err != nil {
+			return nil, err
+		}
+		return &TernaryExpression{Condition: condition, True: trueBranch, False: falseBranch}, nil
+
+	case "UnaryExpression":
+		var wire unaryExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		right, err := UnmarshalExpression(wire.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpression{Operator: wire.Operator, Right: right}, nil
+
+	case "VariableExpression":
+		var wire variableExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		return &VariableExpression{Name: wire.Name}, nil
+
+	case "ListExpression":
+		var wire listExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		elements := make([]Expression, len(wire.Elements))
+		for idx, raw := range wire.Elements {
+			element, err := UnmarshalExpression(raw)
+			if err != nil {
+				return nil, err
+			}
+			elements[idx] = element
+		}
+		return &ListExpression{Bracket: wire.Bracket, Elements: elements}, nil
+
+	case "MapExpression":
+		var wire mapExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		keys := make([]Expression, len(wire.Keys))
+		for idx, raw := range wire.Keys {
+			key, err := UnmarshalExpression(raw)
+			if err != nil {
+				return nil, err
+			}
+			keys[idx] = key
+		}
+		values := make([]Expression, len(wire.Values))
+		for idx, raw := range wire.Values {
+			value, err := UnmarshalExpression(raw)
+			if err != nil {
+				return nil, err
+			}
+			values[idx] = value
+		}
+		return &MapExpression{Brace: wire.Brace, Keys: keys, Values: values}, nil
+
+	case "IndexExpression":
+		var wire indexExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		object, err := UnmarshalExpression(wire.Object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := UnmarshalExpression(wire.Index)
+		if err != nil {
+			return nil, err
+		}
+		return &IndexExpression{Object: object, Bracket: wire.Bracket, Index: index}, nil
+
+	case "IndexSetExpression":
+		var wire indexSetExpressionJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		object, err := UnmarshalExpression(wire.Object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := UnmarshalExpression(wire.Index)
+		if err != nil {
+			return nil, err
+		}
+		value, err := UnmarshalExpression(wire.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &IndexSetExpression{Object: object, Bracket: wire.Bracket, Index: index, Value: value}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown expression kind %q", probe.Kind)
+	}
+}
+
+// --- Statement ---
+
+type expressionStatementJSON struct {
+	Kind       string          `json:"kind"`
+	Expression json.RawMessage `json:"expression"`
+}
+
+type functionStatementJSON struct {
+	Kind       string            `json:"kind"`
+	Name       *Token            `json:"name"`
+	Params     []*Token          `json:"params"`
+	Body       []json.RawMessage `json:"body"`
+	ParamTypes []*Token          `json:"paramTypes,omitempty"`
+	ReturnType *Token            `json:"returnType,omitempty"`
+}
+
+type printStatementJSON struct {
+	Kind       string          `json:"kind"`
+	Expression json.RawMessage `json:"expression"`
+}
+
+type returnStatementJSON struct {
+	Kind    string          `json:"kind"`
+	Keyword *Token          `json:"keyword"`
+	Value   json.RawMessage `json:"value"`
+}
+
+type varStatementJSON struct {
+	Kind        string          `json:"kind"`
+	Name        *Token          `json:"name"`
+	Initializer json.RawMessage `json:"initializer"`
+	Type        *Token          `json:"type,omitempty"`
+}
+
+type blockStatementJSON struct {
+	Kind       string            `json:"kind"`
+	Statements []json.RawMessage `json:"statements"`
+}
+
+type ifStatementJSON struct {
+	Kind      string          `json:"kind"`
+	Condition json.RawMessage `json:"condition"`
+	Then      json.RawMessage `json:"then"`
+	Else      json.RawMessage `json:"else"`
+}
+
+type whileStatementJSON struct {
+	Kind      string          `json:"kind"`
+	Condition json.RawMessage `json:"condition"`
+	Body      json.RawMessage `json:"body"`
+	Label     *Token          `json:"label,omitempty"`
+}
+
+type breakStatementJSON struct {
+	Kind    string `json:"kind"`
+	Keyword *Token `json:"keyword"`
+	Label   *Token `json:"label,omitempty"`
+}
+
+type continueStatementJSON struct {
+	Kind    string `json:"kind"`
+	Keyword *Token `json:"keyword"`
+	Label   *Token `json:"label,omitempty"`
+}
+
+type classStatementJSON struct {
+	Kind       string            `json:"kind"`
+	Name       *Token            `json:"name"`
+	Superclass json.RawMessage   `json:"superclass"`
+	Methods    []json.RawMessage `json:"methods"`
+}
+
+type importStatementJSON struct {
+	Kind    string `json:"kind"`
+	Keyword *Token `json:"keyword"`
+	Path    *Token `json:"path"`
+	Alias   *Token `json:"alias,omitempty"`
+}
+
+type foreachStatementJSON struct {
+	Kind     string          `json:"kind"`
+	Keyword  *Token          `json:"keyword"`
+	Name     *Token          `json:"name"`
+	Iterable json.RawMessage `json:"iterable"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// MarshalStatement encodes a Statement node (and, recursively, its
+// children) tagged with its concrete Go type name as "kind".
+func MarshalStatement(statement Statement) ([]byte, error) {
+	switch s := statement.(type) {
+	case *ExpressionStatement:
+		expression, err := marshalExpr(s.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(expressionStatementJSON{Kind: "ExpressionStatement", Expression: expression})
+
+	case *FunctionStatement:
+		body, err := marshalStmtList(s.Body)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(functionStatementJSON{
+			Kind:       "FunctionStatement",
+			Name:       s.Name,
+			Params:     s.Params,
+			Body:       body,
+			ParamTypes: s.ParamTypes,
+			ReturnType: s.ReturnType,
+		})
+
+	case *PrintStatement:
+		expression, err := marshalExpr(s.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(printStatementJSON{Kind: "PrintStatement", Expression: expression})
+
+	case *ReturnStatement:
+		value, err := marshalExpr(s.Value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(returnStatementJSON{Kind: "ReturnStatement", Keyword: s.Keyword, Value: value})
+
+	case *VarStatement:
+		initializer, err := marshalExpr(s.Initializer)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(varStatementJSON{Kind: "VarStatement", Name: s.Name, Initializer: initializer, Type: s.Type})
+
+	case *BlockStatement:
+		statements, err := marshalStmtList(s.Statements)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(blockStatementJSON{Kind: "BlockStatement", Statements: statements})
+
+	case *IfStatement:
+		condition, err := marshalExpr(s.Condition)
+		if err != nil {
+			return nil, err
+		}
+		then, err := marshalStmt(s.Then)
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := marshalStmt(s.Else)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(ifStatementJSON{Kind: "IfStatement", Condition: condition, Then: then, Else: elseBranch})
+
+	case *WhileStatement:
+		condition, err := marshalExpr(s.Condition)
+		if err != nil {
+			return nil, err
+		}
+		body, err := marshalStmt(s.Body)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(whileStatementJSON{Kind: "WhileStatement", Condition: condition, Body: body, Label: s.Label})
+
+	case *BreakStatement:
+		return json.Marshal(breakStatementJSON{Kind: "BreakStatement", Keyword: s.Keyword, Label: s.Label})
+
+	case *ContinueStatement:
+		return json.Marshal(continueStatementJSON{Kind: "ContinueStatement", Keyword: s.Keyword, Label: s.Label})
+
+	case *ClassStatement:
+		var superclass json.RawMessage
+		var err error
+		if s.Superclass != nil {
+			superclass, err = marshalExpr(s.Superclass)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			superclass = json.RawMessage("null")
+		}
+
+		methods := make([]Statement, len(s.Methods))
+		for idx, method := range s.Methods {
+			methods[idx] = method
+		}
+		methodsRaw, err := marshalStmtList(methods)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(classStatementJSON{Kind: "ClassStatement", Name: s.Name, Superclass: superclass, Methods: methodsRaw})
+
+	case *ImportStatement:
+		return json.Marshal(importStatementJSON{Kind: "ImportStatement", Keyword: s.Keyword, Path: s.Path, Alias: s.Alias})
+
+	case *ForeachStatement:
+		iterable, err := marshalExpr(s.Iterable)
+		if err != nil {
+			return nil, err
+		}
+		body, err := marshalStmt(s.Body)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(foreachStatementJSON{Kind: "ForeachStatement", Keyword: s.Keyword, Name: s.Name, Iterable: iterable, Body: body})
+
+	default:
+		return nil, fmt.Errorf("unsupported statement type %T", statement)
+	}
+}
+
+// UnmarshalStatement reconstructs a Statement from its tagged JSON form.
+func UnmarshalStatement(data json.RawMessage) (Statement, error) {
+	if isNullRaw(data) {
+		return nil, nil
+	}
+
+	var probe struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	switch probe.Kind {
+	case "ExpressionStatement":
+		var wire expressionStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(wire.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &ExpressionStatement{Expression: expression}, nil
+
+	case "FunctionStatement":
+		var wire functionStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		body := make([]Statement, len(wire.Body))
+		for idx, raw := range wire.Body {
+			statement, err := UnmarshalStatement(raw)
+			if err != nil {
+				return nil, err
+			}
+			body[idx] = statement
+		}
+		return &FunctionStatement{
+			Name:       wire.Name,
+			Params:     wire.Params,
+			Body:       body,
+			ParamTypes: wire.ParamTypes,
+			ReturnType: wire.ReturnType,
+		}, nil
+
+	case "PrintStatement":
+		var wire printStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(wire.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &PrintStatement{Expression: expression}, nil
+
+	case "ReturnStatement":
+		var wire returnStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		value, err := UnmarshalExpression(wire.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &ReturnStatement{Keyword: wire.Keyword, Value: value}, nil
+
+	case "VarStatement":
+		var wire varStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		initializer, err := UnmarshalExpression(wire.Initializer)
+		if err != nil {
+			return nil, err
+		}
+		return &VarStatement{Name: wire.Name, Initializer: initializer, Type: wire.Type}, nil
+
+	case "BlockStatement":
+		var wire blockStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		statements := make([]Statement, len(wire.Statements))
+		for idx, raw := range wire.Statements {
+			statement, err := UnmarshalStatement(raw)
+			if err != nil {
+				return nil, err
+			}
+			statements[idx] = statement
+		}
+		return &BlockStatement{Statements: statements}, nil
+
+	case "IfStatement":
+		var wire ifStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		condition, err := UnmarshalExpression(wire.Condition)
+		if err != nil {
+			return nil, err
+		}
+		then, err := UnmarshalStatement(wire.Then)
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := UnmarshalStatement(wire.Else)
+		if err != nil {
+			return nil, err
+		}
+		return &IfStatement{Condition: condition, Then: then, Else: elseBranch}, nil
+
+	case "WhileStatement":
+		var wire whileStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		condition, err := UnmarshalExpression(wire.Condition)
+		if err != nil {
+			return nil, err
+		}
+		body, err := UnmarshalStatement(wire.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &WhileStatement{Condition: condition, Body: body, Label: wire.Label}, nil
+
+	case "BreakStatement":
+		var wire breakStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		return &BreakStatement{Keyword: wire.Keyword, Label: wire.Label}, nil
+
+	case "ContinueStatement":
+		var wire continueStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		return &ContinueStatement{Keyword: wire.Keyword, Label: wire.Label}, nil
+
+	case "ClassStatement":
+		var wire classStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+
+		var superclass *VariableExpression
+		if !isNullRaw(wire.Superclass) {
+			expression, err := UnmarshalExpression(wire.Superclass)
+			if err != nil {
+				return nil, err
+			}
+			variable, ok := expression.(*VariableExpression)
+			if !ok {
+				return nil, fmt.Errorf("ClassStatement superclass must be a VariableExpression, got %T", expression)
+			}
+			superclass = variable
+		}
+
+		methods := make([]*FunctionStatement, len(wire.Methods))
+		for idx, raw := range wire.Methods {
+			statement, err := UnmarshalStatement(raw)
+			if err != nil {
+				return nil, err
+			}
+			function, ok := statement.(*FunctionStatement)
+			if !ok {
+				return nil, fmt.Errorf("ClassStatement method must be a FunctionStatement, got %T", statement)
+			}
+			methods[idx] = function
+		}
+		return &ClassStatement{Name: wire.Name, Superclass: superclass, Methods: methods}, nil
+
+	case "ImportStatement":
+		var wire importStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		return &ImportStatement{Keyword: wire.Keyword, Path: wire.Path, Alias: wire.Alias}, nil
+
+	case "ForeachStatement":
+		var wire foreachStatementJSON
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return nil, err
+		}
+		iterable, err := UnmarshalExpression(wire.Iterable)
+		if err != nil {
+			return nil, err
+		}
+		body, err := UnmarshalStatement(wire.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &ForeachStatement{Keyword: wire.Keyword, Name: wire.Name, Iterable: iterable, Body: body}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown statement kind %q", probe.Kind)
+	}
+}
+
+// MarshalStatements encodes a full program (a top-level []Statement) as a
+// JSON array, for `--emit-ast`.
+func MarshalStatements(statements []Statement) ([]byte, error) {
+	raw, err := marshalStmtList(statements)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalStatements decodes a `--emit-ast`-shaped JSON array back into a
+// program's top-level []Statement, for `--load-ast`.
+func UnmarshalStatements(data []byte) ([]Statement, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	statements := make([]Statement, len(raw))
+	for idx, entry := range raw {
+		statement, err := UnmarshalStatement(entry)
+		if err != nil {
+			return nil, err
+		}
+		statements[idx] = statement
+	}
+	return statements, nil
+}
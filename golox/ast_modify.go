@@ -0,0 +1,277 @@
+package main
+
+// Node is the supertype of every AST node - an Expression or a Statement.
+// The generated expression.go/statement.go don't share one interface (the
+// visitor pattern is split into ExpressionVisitor/StatementVisitor), so
+// Modify needs its own umbrella type to walk both trees uniformly.
+type Node interface{}
+
+// ModifierFunc is applied to every node in the tree, child-first, by
+// Modify. It's handed the already-rebuilt node (with any modified children
+// already in place) and returns the node that should take its place -
+// itself, if no change is wanted.
+type ModifierFunc func(Node) Node
+
+// Modify walks node and every descendant, applying modifier bottom-up, and
+// returns the (possibly replaced) tree. It's the building block for
+// AST-level passes that run between parsing and resolution: constant
+// folding, ternary desugaring, and eventually a quote/unquote macro
+// subsystem (not implemented yet - Modify is the mechanism a future
+// `unquote` pass would reuse to splice evaluated sub-trees back in).
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case Expression:
+		return ModifyExpression(n, modifier)
+	case Statement:
+		return ModifyStatement(n, modifier)
+	default:
+		return node
+	}
+}
+
+// ModifyExpression rebuilds expression with every child passed through
+// Modify, then applies modifier to the rebuilt node.
+func ModifyExpression(expression Expression, modifier ModifierFunc) Expression {
+	if expression == nil {
+		return nil
+	}
+
+	switch e := expression.(type) {
+	case *AssignExpression:
+		expression = &AssignExpression{
+			Name:  e.Name,
+			Value: ModifyExpression(e.Value, modifier),
+		}
+
+	case *BinaryExpression:
+		expression = &BinaryExpression{
+			Left:     ModifyExpression(e.Left, modifier),
+			Operator: e.Operator,
+			Right:    ModifyExpression(e.Right, modifier),
+		}
+
+	case *CallExpression:
+		arguments := make([]Expression, len(e.Arguments))
+		for idx, argument := range e.Arguments {
+			arguments[idx] = ModifyExpression(argument, modifier)
+		}
+		expression = &CallExpression{
+			Callee:    ModifyExpression(e.Callee, modifier),
+			Paren:     e.Paren,
+			Arguments: arguments,
+		}
+
+	case *GetExpression:
+		expression = &GetExpression{
+			Object: ModifyExpression(e.Object, modifier),
+			Name:   e.Name,
+		}
+
+	case *GroupingExpression:
+		expression = &GroupingExpression{
+			Expression: ModifyExpression(e.Expression, modifier),
+		}
+
+	case *LiteralExpression:
+		// no children
+
+	case *LogicalExpression:
+		expression = &LogicalExpression{
+			Left:     ModifyExpression(e.Left, modifier),
+			Operator: e.Operator,
+			Right:    ModifyExpression(e.Right, modifier),
+		}
+
+	case *SetExpression:
+		expression = &SetExpression{
+			Object: ModifyExpression(e.Object, modifier),
+			Name:   e.Name,
+			Value:  ModifyExpression(e.Value, modifier),
+		}
+
+	case *SuperExpression:
+		// no children
+
+	case *ThisExpression:
+		// no children
+
+	case *TernaryExpression:
+		expression = &TernaryExpression{
+			Condition: ModifyExpression(e.Condition, modifier),
+			True:      ModifyExpression(e.True, modifier),
+			False:     ModifyExpression(e.False, modifier),
+		}
+
+	case *UnaryExpression:
+		expression = &UnaryExpression{
+			Operator: e.Operator,
+			Right:    ModifyExpression(e.Right, modifier),
+		}
+
+	case *VariableExpression:
+		// no children
+
+	case *ListExpression:
+		elements := make([]Expression, len(e.Elements))
+		for idx, element := range e.Elements {
+			elements[idx] = ModifyExpression(element, modifier)
+		}
+		expression = &ListExpression{
+			Bracket:  e.Bracket,
+			Elements: elements,
+		}
+
+	case *MapExpression:
+		keys := make([]Expression, len(e.Keys))
+		for idx, key := range e.Keys {
+			keys[idx] = ModifyExpression(key, modifier)
+		}
+		values := make([]Expression, len(e.Values))
+		for idx, value := range e.Values {
+			values[idx] = ModifyExpression(value, modifier)
+		}
+		expression = &MapExpression{
+			Brace:  e.Brace,
+			Keys:   keys,
+			Values: values,
+		}
+
+	case *IndexExpression:
+		expression = &IndexExpression{
+			Object:  ModifyExpression(e.Object, modifier),
+			Bracket: e.Bracket,
+			Index:   ModifyExpression(e.Index, modifier),
+		}
+
+	case *IndexSetExpression:
+		expression = &IndexSetExpression{
+			Object:  ModifyExpression(e.Object, modifier),
+			Bracket: e.Bracket,
+			Index:   ModifyExpression(e.Index, modifier),
+			Value:   ModifyExpression(e.Value, modifier),
+		}
+	}
+
+	modified := modifier(expression)
+	result, ok := modified.(Expression)
+	if !ok {
+		// a modifier that turns an Expression into a Statement (or vice
+		// versa) has nowhere to go in a typed tree - keep the rebuilt node
+		// rather than silently dropping the rewrite.
+		return expression
+	}
+	return result
+}
+
+// ModifyStatement rebuilds statement with every child passed through
+// Modify, then applies modifier to the rebuilt node.
+func ModifyStatement(statement Statement, modifier ModifierFunc) Statement {
+	if statement == nil {
+		return nil
+	}
+
+	switch s := statement.(type) {
+	case *ExpressionStatement:
+		statement = &ExpressionStatement{
+			Expression: ModifyExpression(s.Expression, modifier),
+		}
+
+	case *FunctionStatement:
+		body := make([]Statement, len(s.Body))
+		for idx, bodyStatement := range s.Body {
+			body[idx] = ModifyStatement(bodyStatement, modifier)
+		}
+		statement = &FunctionStatement{
+			Name:       s.Name,
+			Params:     s.Params,
+			Body:       body,
+			ParamTypes: s.ParamTypes,
+			ReturnType: s.ReturnType,
+		}
+
+	case *PrintStatement:
+		statement = &PrintStatement{
+			Expression: ModifyExpression(s.Expression, modifier),
+		}
+
+	case *ReturnStatement:
+		statement = &ReturnStatement{
+			Keyword: s.Keyword,
+			Value:   ModifyExpression(s.Value, modifier),
+		}
+
+	case *VarStatement:
+		statement = &VarStatement{
+			Name:        s.Name,
+			Initializer: ModifyExpression(s.Initializer, modifier),
+			Type:        s.Type,
+		}
+
+	case *BlockStatement:
+		statements := make([]Statement, len(s.Statements))
+		for idx, blockStatement := range s.Statements {
+			statements[idx] = ModifyStatement(blockStatement, modifier)
+		}
+		statement = &BlockStatement{
+			Statements: statements,
+		}
+
+	case *IfStatement:
+		statement = &IfStatement{
+			Condition: ModifyExpression(s.Condition, modifier),
+			Then:      ModifyStatement(s.Then, modifier),
+			Else:      ModifyStatement(s.Else, modifier),
+		}
+
+	case *WhileStatement:
+		statement = &WhileStatement{
+			Condition: ModifyExpression(s.Condition, modifier),
+			Body:      ModifyStatement(s.Body, modifier),
+			Label:     s.Label,
+		}
+
+	case *BreakStatement:
+		// no children
+
+	case *ContinueStatement:
+		// no children
+
+	case *ClassStatement:
+		methods := make([]*FunctionStatement, len(s.Methods))
+		for idx, method := range s.Methods {
+			methods[idx] = ModifyStatement(method, modifier).(*FunctionStatement)
+		}
+		statement = &ClassStatement{
+			Name:       s.Name,
+			Superclass: s.Superclass,
+			Methods:    methods,
+		}
+
+	case *ImportStatement:
+		// no children
+
+	case *ForeachStatement:
+		statement = &ForeachStatement{
+			Keyword:  s.Keyword,
+			Name:     s.Name,
+			Iterable: ModifyExpression(s.Iterable, modifier),
+			Body:     ModifyStatement(s.Body, modifier),
+		}
+	}
+
+	modified := modifier(statement)
+	result, ok := modified.(Statement)
+	if !ok {
+		return statement
+	}
+	return result
+}
+
+// ModifyProgram runs Modify over every top-level statement in a program.
+func ModifyProgram(statements []Statement, modifier ModifierFunc) []Statement {
+	modified := make([]Statement, len(statements))
+	for idx, statement := range statements {
+		modified[idx] = ModifyStatement(statement, modifier)
+	}
+	return modified
+}
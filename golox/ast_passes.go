@@ -0,0 +1,128 @@
+package main
+
+// ConstantFold rewrites every BinaryExpression/UnaryExpression with literal
+// number (or, for '!', literal bool) operands into the already-computed
+// LiteralExpression, using Modify to walk the whole program. Division by
+// zero is left alone rather than folded, so it still reports as a runtime
+// error at the original call site.
+func ConstantFold(statements []Statement) []Statement {
+	modifier := func(node Node) Node {
+		switch e := node.(type) {
+		case *BinaryExpression:
+			return foldBinaryExpression(e)
+		case *UnaryExpression:
+			return foldUnaryExpression(e)
+		default:
+			return node
+		}
+	}
+
+	return ModifyProgram(statements, modifier)
+}
+
+func foldBinaryExpression(expression *BinaryExpression) Expression {
+	left, ok := expression.Left.(*LiteralExpression)
+	if !ok || left.Value.Type != LiteralTypeNumber {
+		return expression
+	}
+
+	right, ok := expression.Right.(*LiteralExpression)
+	if !ok || right.Value.Type != LiteralTypeNumber {
+		return expression
+	}
+
+	switch expression.Operator.Type {
+	case Plus:
+		return &LiteralExpression{Value: NewNumberLiteral(left.Value.NumberValue + right.Value.NumberValue)}
+	case Minus:
+		return &LiteralExpression{Value: NewNumberLiteral(left.Value.NumberValue - right.Value.NumberValue)}
+	case Star:
+		return &LiteralExpression{Value: NewNumberLiteral(left.Value.NumberValue * right.Value.NumberValue)}
+	case Slash:
+		if right.Value.NumberValue == 0 {
+			return expression
+		}
+		return &LiteralExpression{Value: NewNumberLiteral(left.Value.NumberValue / right.Value.NumberValue)}
+	default:
+		return expression
+	}
+}
+
+func foldUnaryExpression(expression *UnaryExpression) Expression {
+	literal, ok := expression.Right.(*LiteralExpression)
+	if !ok {
+		return expression
+	}
+
+	switch expression.Operator.Type {
+	case Minus:
+		if literal.Value.Type != LiteralTypeNumber {
+			return expression
+		}
+		return &LiteralExpression{Value: NewNumberLiteral(-literal.Value.NumberValue)}
+	case Bang:
+		if literal.Value.Type != LiteralTypeBool {
+			return expression
+		}
+		return &LiteralExpression{Value: NewBoolLiteral(!literal.Value.BoolValue)}
+	default:
+		return expression
+	}
+}
+
+// DesugarTernary rewrites a ternary expression that sits directly in
+// statement position - the whole expression of an ExpressionStatement or
+// PrintStatement, or the value of a ReturnStatement - into an IfStatement
+// that runs the same kind of statement on whichever branch the condition
+// selects. A ternary nested inside a larger expression (an operand of a
+// BinaryExpression, say) is left as a TernaryExpression and still
+// evaluated normally by the interpreter; only the statement-position case
+// has an IfStatement equivalent to desugar into.
+func DesugarTernary(statements []Statement) []Statement {
+	modifier := func(node Node) Node {
+		statement, ok := node.(Statement)
+		if !ok {
+			return node
+		}
+
+		switch s := statement.(type) {
+		case *ExpressionStatement:
+			ternary, ok := s.Expression.(*TernaryExpression)
+			if !ok {
+				return statement
+			}
+			return &IfStatement{
+				Condition: ternary.Condition,
+				Then:      &ExpressionStatement{Expression: ternary.True},
+				Else:      &ExpressionStatement{Expression: ternary.False},
+			}
+
+		case *PrintStatement:
+			ternary, ok := s.Expression.(*TernaryExpression)
+			if !ok {
+				return statement
+			}
+			return &IfStatement{
+				Condition: ternary.Condition,
+				Then:      &PrintStatement{Expression: ternary.True},
+				Else:      &PrintStatement{Expression: ternary.False},
+			}
+
+		case *ReturnStatement:
+			ternary, ok := s.Value.(*TernaryExpression)
+			if !ok {
+				return statement
+			}
+			return &IfStatement{
+				Condition: ternary.Condition,
+				Then:      &ReturnStatement{Keyword: s.Keyword, Value: ternary.True},
+				Else:      &ReturnStatement{Keyword: s.Keyword, Value: ternary.False},
+			}
+
+		default:
+			return statement
+		}
+	}
+
+	return ModifyProgram(statements, modifier)
+}
@@ -0,0 +1,226 @@
+package main
+
+// Visitor is implemented by a pass that wants to observe every node under
+// a statement or expression, not just the handful it cares about - much
+// like go/ast.Visitor. Walk calls Visit(node) on the way down; if the
+// returned Visitor is non-nil, Walk uses it to visit each of node's
+// children in turn and then calls it once more with Visit(nil) once
+// they're all done (a post-order hook, again mirroring go/ast.Walk). If
+// Visit returns nil, Walk doesn't descend into node's children at all -
+// the visitor has either already walked them itself (the trick Resolver
+// uses for a node that needs to begin/end a scope around its children) or
+// has no interest in them.
+//
+// Like go/ast.Walk, this is read-only traversal: Visit can inspect (and
+// even mutate) a node's own fields, but it has no way to replace node
+// itself in its parent - that needs a rebuilt tree, which is what Modify
+// (ast_modify.go) is for. See ast_checks.go's ConstantFolder for what that
+// means in practice.
+type Visitor interface {
+	Visit(node Node) (w Visitor, err error)
+}
+
+// Walk traverses node depth-first, calling v.Visit at every node: node
+// itself, then (if Visit asked to continue) each of its children,
+// recursively. node may be an Expression, a Statement, or []Statement (a
+// whole program or block), so a pass can kick off a walk with either
+// Walk(v, statements) or Walk(v, someExpression).
+func Walk(v Visitor, node Node) error {
+	w, err := v.Visit(node)
+	if err != nil || w == nil {
+		return err
+	}
+
+	switch n := node.(type) {
+	case []Statement:
+		for _, statement := range n {
+			if err := Walk(w, statement); err != nil {
+				return err
+			}
+		}
+
+	case *ExpressionStatement:
+		if err := Walk(w, n.Expression); err != nil {
+			return err
+		}
+
+	case *FunctionStatement:
+		for _, statement := range n.Body {
+			if err := Walk(w, statement); err != nil {
+				return err
+			}
+		}
+
+	case *PrintStatement:
+		if err := Walk(w, n.Expression); err != nil {
+			return err
+		}
+
+	case *ReturnStatement:
+		if n.Value != nil {
+			if err := Walk(w, n.Value); err != nil {
+				return err
+			}
+		}
+
+	case *VarStatement:
+		if n.Initializer != nil {
+			if err := Walk(w, n.Initializer); err != nil {
+				return err
+			}
+		}
+
+	case *BlockStatement:
+		for _, statement := range n.Statements {
+			if err := Walk(w, statement); err != nil {
+				return err
+			}
+		}
+
+	case *IfStatement:
+		if err := Walk(w, n.Condition); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Then); err != nil {
+			return err
+		}
+		if n.Else != nil {
+			if err := Walk(w, n.Else); err != nil {
+				return err
+			}
+		}
+
+	case *WhileStatement:
+		if err := Walk(w, n.Condition); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Body); err != nil {
+			return err
+		}
+
+	case *BreakStatement, *ContinueStatement, *ImportStatement:
+		// no children
+
+	case *ClassStatement:
+		for _, method := range n.Methods {
+			if err := Walk(w, method); err != nil {
+				return err
+			}
+		}
+
+	case *ForeachStatement:
+		if err := Walk(w, n.Iterable); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Body); err != nil {
+			return err
+		}
+
+	case *AssignExpression:
+		if err := Walk(w, n.Value); err != nil {
+			return err
+		}
+
+	case *BinaryExpression:
+		if err := Walk(w, n.Left); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Right); err != nil {
+			return err
+		}
+
+	case *CallExpression:
+		if err := Walk(w, n.Callee); err != nil {
+			return err
+		}
+		for _, argument := range n.Arguments {
+			if err := Walk(w, argument); err != nil {
+				return err
+			}
+		}
+
+	case *GetExpression:
+		if err := Walk(w, n.Object); err != nil {
+			return err
+		}
+
+	case *GroupingExpression:
+		if err := Walk(w, n.Expression); err != nil {
+			return err
+		}
+
+	case *LiteralExpression, *VariableExpression, *SuperExpression, *ThisExpression:
+		// no children
+
+	case *LogicalExpression:
+		if err := Walk(w, n.Left); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Right); err != nil {
+			return err
+		}
+
+	case *SetExpression:
+		if err := Walk(w, n.Value); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Object); err != nil {
+			return err
+		}
+
+	case *TernaryExpression:
+		if err := Walk(w, n.Condition); err != nil {
+			return err
+		}
+		if err := Walk(w, n.True); err != nil {
+			return err
+		}
+		if err := Walk(w, n.False); err != nil {
+			return err
+		}
+
+	case *UnaryExpression:
+		if err := Walk(w, n.Right); err != nil {
+			return err
+		}
+
+	case *ListExpression:
+		for _, element := range n.Elements {
+			if err := Walk(w, element); err != nil {
+				return err
+			}
+		}
+
+	case *MapExpression:
+		for idx, key := range n.Keys {
+			if err := Walk(w, key); err != nil {
+				return err
+			}
+			if err := Walk(w, n.Values[idx]); err != nil {
+				return err
+			}
+		}
+
+	case *IndexExpression:
+		if err := Walk(w, n.Object); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Index); err != nil {
+			return err
+		}
+
+	case *IndexSetExpression:
+		if err := Walk(w, n.Value); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Object); err != nil {
+			return err
+		}
+		if err := Walk(w, n.Index); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Visit(nil)
+	return err
+}
@@ -0,0 +1,145 @@
+package main
+
+// OpCode is one bytecode instruction understood by the VM. Operands (a
+// constant pool index, a local slot, a jump offset, ...) are packed as
+// raw bytes immediately following the opcode in Chunk.Code; see
+// disassembler.go for how each one is decoded back out for printing.
+type OpCode byte
+
+const (
+	OpConstant OpCode = iota
+	OpNil
+	OpTrue
+	OpFalse
+	OpPop
+
+	OpGetLocal
+	OpSetLocal
+	OpGetGlobal
+	OpDefineGlobal
+	OpSetGlobal
+	OpGetUpvalue
+	OpSetUpvalue
+	OpGetProperty
+	OpSetProperty
+
+	OpEqual
+	OpGreater
+	OpLess
+	OpAdd
+	OpSubtract
+	OpMultiply
+	OpDivide
+	OpNot
+	OpNegate
+
+	OpPrint
+
+	OpJump
+	OpJumpIfFalse
+	OpLoop
+
+	OpCall
+	OpClosure
+	OpCloseUpvalue
+	OpReturn
+
+	OpClass
+	OpMethod
+)
+
+// TODO: use stringer to generate this
+func (op OpCode) String() string {
+	return [...]string{
+		"OpConstant",
+		"OpNil",
+		"OpTrue",
+		"OpFalse",
+		"OpPop",
+
+		"OpGetLocal",
+		"OpSetLocal",
+		"OpGetGlobal",
+		"OpDefineGlobal",
+		"OpSetGlobal",
+		"OpGetUpvalue",
+		"OpSetUpvalue",
+		"OpGetProperty",
+		"OpSetProperty",
+
+		"OpEqual",
+		"OpGreater",
+		"OpLess",
+		"OpAdd",
+		"OpSubtract",
+		"OpMultiply",
+		"OpDivide",
+		"OpNot",
+		"OpNegate",
+
+		"OpPrint",
+
+		"OpJump",
+		"OpJumpIfFalse",
+		"OpLoop",
+
+		"OpCall",
+		"OpClosure",
+		"OpCloseUpvalue",
+		"OpReturn",
+
+		"OpClass",
+		"OpMethod",
+	}[op]
+}
+
+// Chunk is a flat, linear bytecode program: opcodes and operands packed
+// into Code, one source line per byte of Code in Lines (so a runtime
+// error can still report "[line N]" the way the tree-walk backend does),
+// and the constant pool that OpConstant/OpClosure/OpClass/OpMethod index
+// into.
+type Chunk struct {
+	Code      []byte
+	Lines     []uint
+	Constants []Value
+}
+
+func NewChunk() *Chunk {
+	return &Chunk{}
+}
+
+// Write appends a raw byte (an opcode or an operand byte) to the chunk.
+func (c *Chunk) Write(b byte, line uint) int {
+	c.Code = append(c.Code, b)
+	c.Lines = append(c.Lines, line)
+	return len(c.Code) - 1
+}
+
+func (c *Chunk) WriteOp(op OpCode, line uint) int {
+	return c.Write(byte(op), line)
+}
+
+// WriteUint16 appends a two-byte, big-endian operand (a jump offset) and
+// returns the offset of its first byte, so the caller can come back and
+// patch it once the jump target is known.
+func (c *Chunk) WriteUint16(value uint16, line uint) int {
+	offset := c.Write(byte(value>>8), line)
+	c.Write(byte(value), line)
+	return offset
+}
+
+func (c *Chunk) PatchUint16(offset int, value uint16) {
+	c.Code[offset] = byte(value >> 8)
+	c.Code[offset+1] = byte(value)
+}
+
+func (c *Chunk) ReadUint16(offset int) uint16 {
+	return uint16(c.Code[offset])<<8 | uint16(c.Code[offset+1])
+}
+
+// AddConstant appends value to the constant pool and returns its index,
+// the operand OpConstant (and the closure/class opcodes) encode.
+func (c *Chunk) AddConstant(value Value) int {
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
@@ -0,0 +1,702 @@
+package main
+
+import "fmt"
+
+// BytecodeFunction is a compiled function: its arity, how many upvalues its
+// closures need to capture, and the Chunk holding its body. It's the VM
+// backend's counterpart to LoxFunction - analogous data, but produced by
+// Compile instead of captured directly off a FunctionStatement.
+type BytecodeFunction struct {
+	Name         string
+	Arity        int
+	UpvalueCount int
+	Chunk        *Chunk
+}
+
+func (f *BytecodeFunction) String() string {
+	if f.Name == "" {
+		return "<script>"
+	}
+	return fmt.Sprintf("<fn %s>", f.Name)
+}
+
+// UpvalueRef records how a closure fills in one of its upvalue slots:
+// either by capturing a local slot straight out of the enclosing
+// function's own frame (IsLocal), or by capturing one of the enclosing
+// function's own upvalues (so a doubly-nested closure reaches through).
+type UpvalueRef struct {
+	Index   byte
+	IsLocal bool
+}
+
+// BytecodeClass is the VM backend's class representation: a name and its
+// compiled methods. It's kept separate from LoxClass rather than sharing
+// it, since LoxClass.Methods is typed to the tree-walk backend's
+// *LoxFunction and the two backends' closures aren't interchangeable.
+type BytecodeClass struct {
+	ClassName string
+	Methods   map[string]*BytecodeClosure
+}
+
+func (c *BytecodeClass) Name() string { return c.ClassName }
+func (c *BytecodeClass) String() string {
+	return c.ClassName
+}
+
+type local struct {
+	name string
+
+	// -1 between declareLocal and defineLocal (for a class, while its
+	// methods are compiled). A VarStatement's initializer is compiled
+	// before declareLocal runs, so a reference to the name in its own
+	// initializer never sees this local and resolves to an enclosing
+	// local or a global instead.
+	depth int
+
+	// set once resolveUpvalue finds this local captured by a nested
+	// function, so endScope emits OpCloseUpvalue instead of OpPop for it.
+	isCaptured bool
+}
+
+// Compiler compiles one function's body (the top-level script counts as a
+// function) into bytecode. Each nested FunctionStatement gets its own
+// Compiler chained via enclosing, mirroring the call stack that will exist
+// at runtime - resolveUpvalue walks that chain to capture variables from
+// outside the current function.
+type Compiler struct {
+	enclosing *Compiler
+
+	interpreter *Interpreter
+
+	function *BytecodeFunction
+	kind     FunctionKind
+
+	locals     []local
+	upvalues   []UpvalueRef
+	scopeDepth int
+
+	// loop holds the break/continue jump-patch lists for the innermost
+	// enclosing loop, nil outside of one.
+	loop *loopContext
+}
+
+type loopContext struct {
+	enclosing *loopContext
+
+	// label is this loop's label, or "" if it's unlabeled - see
+	// WhileStatement.Label. findLoop uses it to let a break/continue
+	// target an outer loop by name instead of just the nearest one.
+	label string
+
+	// continueTarget is the bytecode offset OpLoop jumps back to.
+	continueTarget int
+
+	// breakJumps collects every break's OpJump offset so they can all be
+	// patched to land just past the loop once it's fully compiled.
+	breakJumps []int
+}
+
+// Compile compiles a fully-resolved program - the Resolver must already
+// have run, since reading a variable's distance out of
+// interpreter.Locals is how the compiler tells a local/upvalue reference
+// apart from a global one - into a top-level BytecodeFunction. This is
+// the VM backend's counterpart to Interpreter.Interpret, selected with
+// --backend=vm.
+func Compile(interpreter *Interpreter, statements []Statement) *BytecodeFunction {
+	c := newCompiler(interpreter, nil, FunctionKindFunction)
+
+	for _, statement := range statements {
+		c.compileStatement(statement)
+	}
+	c.emitReturn(0)
+
+	return c.function
+}
+
+func newCompiler(interpreter *Interpreter, enclosing *Compiler, kind FunctionKind) *Compiler {
+	c := &Compiler{
+		enclosing:   enclosing,
+		interpreter: interpreter,
+		kind:        kind,
+		function: &BytecodeFunction{
+			Chunk: NewChunk(),
+		},
+	}
+
+	// Slot 0 of every frame is reserved: 'this' for a method, otherwise
+	// left unnamed and unused (top-level locals start at slot 1).
+	if kind == FunctionKindMethod {
+		c.locals = append(c.locals, local{name: "this", depth: 0})
+	} else {
+		c.locals = append(c.locals, local{name: "", depth: 0})
+	}
+
+	return c
+}
+
+// error reports message through c.interpreter.Diagnostics, the same way
+// Resolver/TypeChecker surface a compile-time problem. token is nil for
+// errors that aren't tied to one syntax node (an unsupported
+// statement/expression kind).
+func (c *Compiler) error(token *Token, message string) {
+	c.interpreter.Diagnostics.Add(PhaseCompile, token, message)
+}
+
+func (c *Compiler) emit(b byte, line uint) int {
+	return c.function.Chunk.Write(b, line)
+}
+
+func (c *Compiler) emitOp(op OpCode, line uint) int {
+	return c.function.Chunk.WriteOp(op, line)
+}
+
+func (c *Compiler) emitOpByte(op OpCode, operand byte, line uint) {
+	c.emitOp(op, line)
+	c.emit(operand, line)
+}
+
+func (c *Compiler) emitConstant(value Value, line uint) {
+	index := c.function.Chunk.AddConstant(value)
+	c.emitOpByte(OpConstant, byte(index), line)
+}
+
+func (c *Compiler) emitReturn(line uint) {
+	c.emitOp(OpNil, line)
+	c.emitOp(OpReturn, line)
+}
+
+// emitJump writes op followed by a placeholder 16-bit offset and returns
+// where that offset lives, for patchJump to fill in once the target is
+// known.
+func (c *Compiler) emitJump(op OpCode, line uint) int {
+	c.emitOp(op, line)
+	return c.function.Chunk.WriteUint16(0xffff, line)
+}
+
+func (c *Compiler) patchJump(offset int) {
+	jump := len(c.function.Chunk.Code) - offset - 2
+	c.function.Chunk.PatchUint16(offset, uint16(jump))
+}
+
+// emitLoop emits OpLoop with the backward offset to loopStart, the
+// OpJump/patchJump pair run in reverse.
+func (c *Compiler) emitLoop(loopStart int, line uint) {
+	c.emitOp(OpLoop, line)
+
+	offset := len(c.function.Chunk.Code) - loopStart + 2
+	c.function.Chunk.WriteUint16(uint16(offset), line)
+}
+
+func (c *Compiler) beginScope() {
+	c.scopeDepth++
+}
+
+// endScope pops every local declared in the scope being left, closing it
+// over to the heap first (OpCloseUpvalue) if a nested closure captured it.
+func (c *Compiler) endScope(line uint) {
+	c.scopeDepth--
+
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		if c.locals[len(c.locals)-1].isCaptured {
+			c.emitOp(OpCloseUpvalue, line)
+		} else {
+			c.emitOp(OpPop, line)
+		}
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+func (c *Compiler) declareLocal(name *Token) {
+	if c.scopeDepth == 0 {
+		// globals aren't tracked as locals - OpDefineGlobal/OpGetGlobal
+		// look them up by name at runtime instead
+		return
+	}
+
+	for idx := len(c.locals) - 1; idx >= 0; idx-- {
+		if c.locals[idx].depth != -1 && c.locals[idx].depth < c.scopeDepth {
+			break
+		}
+		if c.locals[idx].name == name.Lexeme {
+			c.error(name, "Already a variable with this name in this scope.")
+		}
+	}
+
+	c.locals = append(c.locals, local{name: name.Lexeme, depth: -1})
+}
+
+func (c *Compiler) defineLocal() {
+	if c.scopeDepth == 0 {
+		return
+	}
+	c.locals[len(c.locals)-1].depth = c.scopeDepth
+}
+
+func (c *Compiler) resolveLocal(name string) int {
+	for idx := len(c.locals) - 1; idx >= 0; idx-- {
+		if c.locals[idx].name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// resolveUpvalue walks the chain of enclosing Compilers looking for name
+// as a local, capturing it into an upvalue at every level on the way back
+// down so each intermediate closure can hand it to the next. Returns -1
+// if name isn't a local anywhere up the chain (it's a global).
+func (c *Compiler) resolveUpvalue(name string) int {
+	if c.enclosing == nil {
+		return -1
+	}
+
+	if local := c.enclosing.resolveLocal(name); local != -1 {
+		c.enclosing.locals[local].isCaptured = true
+		return c.addUpvalue(byte(local), true)
+	}
+
+	if upvalue := c.enclosing.resolveUpvalue(name); upvalue != -1 {
+		return c.addUpvalue(byte(upvalue), false)
+	}
+
+	return -1
+}
+
+func (c *Compiler) addUpvalue(index byte, isLocal bool) int {
+	for idx, existing := range c.upvalues {
+		if existing.Index == index && existing.IsLocal == isLocal {
+			return idx
+		}
+	}
+
+	c.upvalues = append(c.upvalues, UpvalueRef{Index: index, IsLocal: isLocal})
+	c.function.UpvalueCount = len(c.upvalues)
+	return len(c.upvalues) - 1
+}
+
+func (c *Compiler) compileStatements(statements []Statement) {
+	for _, statement := range statements {
+		c.compileStatement(statement)
+	}
+}
+
+func (c *Compiler) compileStatement(statement Statement) {
+	switch s := statement.(type) {
+	case *ExpressionStatement:
+		c.compileExpression(s.Expression)
+		c.emitOp(OpPop, 0)
+	case *PrintStatement:
+		c.compileExpression(s.Expression)
+		c.emitOp(OpPrint, 0)
+	case *VarStatement:
+		c.compileVarStatement(s)
+	case *BlockStatement:
+		c.beginScope()
+		c.compileStatements(s.Statements)
+		c.endScope(0)
+	case *IfStatement:
+		c.compileIfStatement(s)
+	case *WhileStatement:
+		c.compileWhileStatement(s)
+	case *BreakStatement:
+		c.compileBreakStatement(s)
+	case *ContinueStatement:
+		c.compileContinueStatement(s)
+	case *FunctionStatement:
+		c.compileFunctionStatement(s)
+	case *ReturnStatement:
+		c.compileReturnStatement(s)
+	case *ClassStatement:
+		c.compileClassStatement(s)
+	default:
+		// ImportStatement/ForeachStatement aren't compiled to bytecode
+		// yet - only the tree-walk backend runs them, same as how
+		// TypeChecker only checks what annotations can prove.
+		c.error(nil, fmt.Sprintf("%T isn't supported by --backend=vm yet", statement))
+	}
+}
+
+func (c *Compiler) compileVarStatement(s *VarStatement) {
+	if s.Initializer != nil {
+		c.compileExpression(s.Initializer)
+	} else {
+		c.emitOp(OpNil, s.Name.Line)
+	}
+
+	if c.scopeDepth > 0 {
+		c.declareLocal(s.Name)
+		c.defineLocal()
+		return
+	}
+
+	index := c.function.Chunk.AddConstant(NewStringValue(s.Name.Lexeme))
+	c.emitOpByte(OpDefineGlobal, byte(index), s.Name.Line)
+}
+
+func (c *Compiler) compileIfStatement(s *IfStatement) {
+	c.compileExpression(s.Condition)
+
+	thenJump := c.emitJump(OpJumpIfFalse, 0)
+	c.emitOp(OpPop, 0)
+	c.compileStatement(s.Then)
+
+	elseJump := c.emitJump(OpJump, 0)
+	c.patchJump(thenJump)
+	c.emitOp(OpPop, 0)
+
+	if s.Else != nil {
+		c.compileStatement(s.Else)
+	}
+	c.patchJump(elseJump)
+}
+
+func (c *Compiler) compileWhileStatement(s *WhileStatement) {
+	loopStart := len(c.function.Chunk.Code)
+
+	c.loop = &loopContext{enclosing: c.loop, continueTarget: loopStart, label: tokenName(s.Label)}
+
+	c.compileExpression(s.Condition)
+	exitJump := c.emitJump(OpJumpIfFalse, 0)
+	c.emitOp(OpPop, 0)
+
+	c.compileStatement(s.Body)
+	c.emitLoop(loopStart, 0)
+
+	c.patchJump(exitJump)
+	c.emitOp(OpPop, 0)
+
+	for _, breakJump := range c.loop.breakJumps {
+		c.patchJump(breakJump)
+	}
+	c.loop = c.loop.enclosing
+}
+
+func (c *Compiler) compileBreakStatement(s *BreakStatement) {
+	loop := c.findLoop(s.Label)
+	if loop == nil {
+		c.reportMissingLoop(s.Keyword, s.Label, "break")
+		return
+	}
+	loop.breakJumps = append(loop.breakJumps, c.emitJump(OpJump, s.Keyword.Line))
+}
+
+func (c *Compiler) compileContinueStatement(s *ContinueStatement) {
+	loop := c.findLoop(s.Label)
+	if loop == nil {
+		c.reportMissingLoop(s.Keyword, s.Label, "continue")
+		return
+	}
+	c.emitLoop(loop.continueTarget, s.Keyword.Line)
+}
+
+// findLoop walks the enclosing-loop chain for the loop label names, or
+// (label nil) just the nearest one - the same rule VisitWhileStatement
+// uses to decide whether a BreakError/ContinueError belongs to it.
+func (c *Compiler) findLoop(label *Token) *loopContext {
+	for loop := c.loop; loop != nil; loop = loop.enclosing {
+		if label == nil || loop.label == label.Lexeme {
+			return loop
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) reportMissingLoop(keyword *Token, label *Token, kind string) {
+	if label != nil {
+		c.error(keyword, fmt.Sprintf("No enclosing loop labeled '%s'.", label.Lexeme))
+		return
+	}
+	c.error(keyword, fmt.Sprintf("Can't %s outside of a loop.", kind))
+}
+
+func (c *Compiler) compileFunctionStatement(s *FunctionStatement) {
+	// Declared (and, for locals, defined) before its body is compiled so
+	// the function can call itself recursively by name.
+	if c.scopeDepth > 0 {
+		c.declareLocal(s.Name)
+		c.defineLocal()
+	}
+
+	function, upvalues := c.compileFunction(s, FunctionKindFunction)
+	c.emitClosure(function, upvalues, s.Name.Line)
+
+	if c.scopeDepth > 0 {
+		// OpClosure's result is already sitting on top of the stack in
+		// exactly the slot declareLocal reserved for it - no OpSetLocal
+		// needed, the same as a local VarStatement's initializer value.
+		return
+	}
+
+	index := c.function.Chunk.AddConstant(NewStringValue(s.Name.Lexeme))
+	c.emitOpByte(OpDefineGlobal, byte(index), s.Name.Line)
+}
+
+// compileFunction compiles declaration's body with a fresh, nested
+// Compiler and returns the result plus the upvalues it needs captured;
+// the caller (a FunDecl or a class's method list) is responsible for
+// emitting the OpClosure that wraps it.
+func (c *Compiler) compileFunction(declaration *FunctionStatement, kind FunctionKind) (*BytecodeFunction, []UpvalueRef) {
+	inner := newCompiler(c.interpreter, c, kind)
+	inner.function.Name = declaration.Name.Lexeme
+	inner.function.Arity = len(declaration.Params)
+
+	inner.beginScope()
+	for _, param := range declaration.Params {
+		inner.declareLocal(param)
+		inner.defineLocal()
+	}
+
+	inner.compileStatements(declaration.Body)
+	inner.emitReturn(declaration.Name.Line)
+
+	return inner.function, inner.upvalues
+}
+
+// emitClosure emits OpClosure for function along with one (isLocal, index)
+// operand pair per upvalue it captures - VM.run's OpClosure case reads
+// exactly this many pairs back off the chunk to build the Upvalue slice.
+func (c *Compiler) emitClosure(function *BytecodeFunction, upvalues []UpvalueRef, line uint) {
+	index := c.function.Chunk.AddConstant(NewBytecodeFunctionValue(function))
+	c.emitOpByte(OpClosure, byte(index), line)
+
+	for _, upvalue := range upvalues {
+		if upvalue.IsLocal {
+			c.emit(1, line)
+		} else {
+			c.emit(0, line)
+		}
+		c.emit(upvalue.Index, line)
+	}
+}
+
+func (c *Compiler) compileReturnStatement(s *ReturnStatement) {
+	if s.Value != nil {
+		c.compileExpression(s.Value)
+	} else {
+		c.emitOp(OpNil, s.Keyword.Line)
+	}
+	c.emitOp(OpReturn, s.Keyword.Line)
+}
+
+// compileClassStatement emits OpClass (which has the VM allocate a fresh,
+// empty BytecodeClass named s.Name) followed by one OpClosure+OpMethod pair
+// per method - OpMethod pops the closure the preceding OpClosure pushed
+// and files it into the class value still sitting under it on the stack,
+// the same "build it up on the stack, then bind the name" shape
+// VarStatement/compileFunctionStatement use for their own declarations.
+func (c *Compiler) compileClassStatement(s *ClassStatement) {
+	if s.Superclass != nil {
+		c.error(s.Superclass.Name, "Inheritance isn't supported by --backend=vm yet.")
+	}
+
+	if c.scopeDepth > 0 {
+		c.declareLocal(s.Name)
+	}
+
+	nameIndex := c.function.Chunk.AddConstant(NewStringValue(s.Name.Lexeme))
+	c.emitOpByte(OpClass, byte(nameIndex), s.Name.Line)
+
+	for _, method := range s.Methods {
+		function, upvalues := c.compileFunction(method, FunctionKindMethod)
+		c.emitClosure(function, upvalues, method.Name.Line)
+
+		methodIndex := c.function.Chunk.AddConstant(NewStringValue(method.Name.Lexeme))
+		c.emitOpByte(OpMethod, byte(methodIndex), method.Name.Line)
+	}
+
+	if c.scopeDepth > 0 {
+		c.defineLocal()
+		return
+	}
+
+	index := c.function.Chunk.AddConstant(NewStringValue(s.Name.Lexeme))
+	c.emitOpByte(OpDefineGlobal, byte(index), s.Name.Line)
+}
+
+func (c *Compiler) compileExpression(expression Expression) {
+	switch e := expression.(type) {
+	case *LiteralExpression:
+		c.compileLiteral(e)
+	case *GroupingExpression:
+		c.compileExpression(e.Expression)
+	case *UnaryExpression:
+		c.compileExpression(e.Right)
+		switch e.Operator.Type {
+		case Minus:
+			c.emitOp(OpNegate, e.Operator.Line)
+		case Bang:
+			c.emitOp(OpNot, e.Operator.Line)
+		}
+	case *BinaryExpression:
+		c.compileBinary(e)
+	case *LogicalExpression:
+		c.compileLogical(e)
+	case *TernaryExpression:
+		c.compileTernary(e)
+	case *VariableExpression:
+		c.compileVariableGet(e, e.Name)
+	case *AssignExpression:
+		c.compileExpression(e.Value)
+		c.compileVariableSet(e, e.Name)
+	case *CallExpression:
+		c.compileCall(e)
+	case *GetExpression:
+		c.compileExpression(e.Object)
+		index := c.function.Chunk.AddConstant(NewStringValue(e.Name.Lexeme))
+		c.emitOpByte(OpGetProperty, byte(index), e.Name.Line)
+	case *SetExpression:
+		c.compileExpression(e.Object)
+		c.compileExpression(e.Value)
+		index := c.function.Chunk.AddConstant(NewStringValue(e.Name.Lexeme))
+		c.emitOpByte(OpSetProperty, byte(index), e.Name.Line)
+	case *ThisExpression:
+		c.compileVariableGet(e, e.Keyword)
+	default:
+		c.error(nil, fmt.Sprintf("%T isn't supported by --backend=vm yet", expression))
+	}
+}
+
+func (c *Compiler) compileLiteral(e *LiteralExpression) {
+	switch e.Value.Type {
+	case LiteralTypeNil:
+		c.emitOp(OpNil, 0)
+	case LiteralTypeBool:
+		if e.Value.BoolValue {
+			c.emitOp(OpTrue, 0)
+		} else {
+			c.emitOp(OpFalse, 0)
+		}
+	default:
+		value, err := NewValue(e.Value)
+		if err != nil {
+			c.error(nil, err.Error())
+			return
+		}
+		c.emitConstant(value, 0)
+	}
+}
+
+func (c *Compiler) compileBinary(e *BinaryExpression) {
+	c.compileExpression(e.Left)
+
+	if e.Operator.Type == Comma {
+		c.emitOp(OpPop, e.Operator.Line)
+		c.compileExpression(e.Right)
+		return
+	}
+
+	c.compileExpression(e.Right)
+
+	line := e.Operator.Line
+	switch e.Operator.Type {
+	case Plus:
+		c.emitOp(OpAdd, line)
+	case Minus:
+		c.emitOp(OpSubtract, line)
+	case Star:
+		c.emitOp(OpMultiply, line)
+	case Slash:
+		c.emitOp(OpDivide, line)
+	case Greater:
+		c.emitOp(OpGreater, line)
+	case GreaterEqual:
+		c.emitOp(OpLess, line)
+		c.emitOp(OpNot, line)
+	case Less:
+		c.emitOp(OpLess, line)
+	case LessEqual:
+		c.emitOp(OpGreater, line)
+		c.emitOp(OpNot, line)
+	case EqualEqual:
+		c.emitOp(OpEqual, line)
+	case BangEqual:
+		c.emitOp(OpEqual, line)
+		c.emitOp(OpNot, line)
+	default:
+		c.error(e.Operator, fmt.Sprintf("unsupported binary operator %s", e.Operator.Type))
+	}
+}
+
+func (c *Compiler) compileLogical(e *LogicalExpression) {
+	c.compileExpression(e.Left)
+
+	if e.Operator.Type == Or {
+		elseJump := c.emitJump(OpJumpIfFalse, e.Operator.Line)
+		endJump := c.emitJump(OpJump, e.Operator.Line)
+		c.patchJump(elseJump)
+		c.emitOp(OpPop, e.Operator.Line)
+		c.compileExpression(e.Right)
+		c.patchJump(endJump)
+		return
+	}
+
+	// And
+	endJump := c.emitJump(OpJumpIfFalse, e.Operator.Line)
+	c.emitOp(OpPop, e.Operator.Line)
+	c.compileExpression(e.Right)
+	c.patchJump(endJump)
+}
+
+func (c *Compiler) compileTernary(e *TernaryExpression) {
+	c.compileExpression(e.Condition)
+
+	thenJump := c.emitJump(OpJumpIfFalse, 0)
+	c.emitOp(OpPop, 0)
+	c.compileExpression(e.True)
+
+	elseJump := c.emitJump(OpJump, 0)
+	c.patchJump(thenJump)
+	c.emitOp(OpPop, 0)
+	c.compileExpression(e.False)
+	c.patchJump(elseJump)
+}
+
+func (c *Compiler) compileCall(e *CallExpression) {
+	c.compileExpression(e.Callee)
+	for _, argument := range e.Arguments {
+		c.compileExpression(argument)
+	}
+	c.emitOpByte(OpCall, byte(len(e.Arguments)), e.Paren.Line)
+}
+
+// compileVariableGet/compileVariableSet classify name's reference the same
+// way the tree-walk backend does - Interpreter.Locals (filled in by
+// Resolver) says whether it's local/upvalue vs. global - but, unlike the
+// tree-walk backend's scope-distance lookup, the actual slot index comes
+// from this Compiler's own locals table, since the bytecode VM addresses
+// locals by flat stack slot rather than by walking Environment pointers.
+func (c *Compiler) compileVariableGet(expression Expression, name *Token) {
+	_, isLocalOrUpvalue := c.interpreter.Locals[expression]
+
+	if isLocalOrUpvalue {
+		if slot := c.resolveLocal(name.Lexeme); slot != -1 {
+			c.emitOpByte(OpGetLocal, byte(slot), name.Line)
+			return
+		}
+		if slot := c.resolveUpvalue(name.Lexeme); slot != -1 {
+			c.emitOpByte(OpGetUpvalue, byte(slot), name.Line)
+			return
+		}
+	}
+
+	index := c.function.Chunk.AddConstant(NewStringValue(name.Lexeme))
+	c.emitOpByte(OpGetGlobal, byte(index), name.Line)
+}
+
+func (c *Compiler) compileVariableSet(expression Expression, name *Token) {
+	_, isLocalOrUpvalue := c.interpreter.Locals[expression]
+
+	if isLocalOrUpvalue {
+		if slot := c.resolveLocal(name.Lexeme); slot != -1 {
+			c.emitOpByte(OpSetLocal, byte(slot), name.Line)
+			return
+		}
+		if slot := c.resolveUpvalue(name.Lexeme); slot != -1 {
+			c.emitOpByte(OpSetUpvalue, byte(slot), name.Line)
+			return
+		}
+	}
+
+	index := c.function.Chunk.AddConstant(NewStringValue(name.Lexeme))
+	c.emitOpByte(OpSetGlobal, byte(index), name.Line)
+}
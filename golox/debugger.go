@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Debugger is a Tracer that pauses a tree-walk run and answers step/
+// breakpoint/inspection commands typed at a terminal, activated by
+// --debug-repl. Where DebugServer speaks a DAP-inspired JSON protocol for
+// an editor to drive, Debugger speaks a small line-oriented command
+// language meant for a human at golox's own stdin/stdout: step, next,
+// continue, break <file>:<line>, bt, locals, watch <name>, and disas.
+type Debugger struct {
+	interpreter *Interpreter
+
+	reader *bufio.Scanner
+	writer io.Writer
+
+	// breakpoints is keyed by file name, then line, so the same line
+	// number in two different imported modules doesn't collide. A
+	// statement with no File (the REPL, a synthesized token) is keyed
+	// under "".
+	breakpoints map[string]map[int]bool
+
+	// watches are variable names printed (from whichever scope is
+	// innermost at the time) every time execution pauses.
+	watches []string
+
+	frames    []CallFrame
+	stepping  string
+	baseDepth int
+
+	// chunk/chunkName are set by SetChunk for a --backend=vm run, so
+	// `disas` has something to print - BeforeStatement/AfterStatement
+	// below never fire for that backend, since the VM doesn't call back
+	// into the Interpreter per statement.
+	chunk     *Chunk
+	chunkName string
+}
+
+// NewDebugger wires a Debugger to interpreter as its Tracer, reading
+// commands from in and writing prompts/output to out. It starts in step
+// mode, so the very first statement pauses before running.
+func NewDebugger(interpreter *Interpreter, in io.Reader, out io.Writer) *Debugger {
+	d := &Debugger{
+		interpreter: interpreter,
+		reader:      bufio.NewScanner(in),
+		writer:      out,
+		breakpoints: map[string]map[int]bool{},
+		stepping:    stepIn,
+	}
+	interpreter.Tracer = d
+	return d
+}
+
+// SetChunk attaches the bytecode compiled for a --backend=vm run - see
+// Inspect.
+func (d *Debugger) SetChunk(chunk *Chunk, name string) {
+	d.chunk = chunk
+	d.chunkName = name
+}
+
+// Inspect runs one command session before a --backend=vm program starts
+// executing, so `disas` is available even though stepping and breakpoints
+// aren't (the VM never calls BeforeStatement/AfterStatement).
+func (d *Debugger) Inspect() {
+	fmt.Fprintln(d.writer, "paused before running compiled bytecode (backend=vm); 'disas' to inspect, 'continue' to run")
+	for {
+		command, _, ok := d.nextCommand()
+		if !ok {
+			return
+		}
+
+		switch command {
+		case "disas":
+			d.disas()
+		case "continue", "c":
+			return
+		default:
+			fmt.Fprintf(d.writer, "unknown or unsupported command %q under --backend=vm; try 'disas' or 'continue'\n", command)
+		}
+	}
+}
+
+func (d *Debugger) BeforeStatement(statement Statement, frames []CallFrame) {
+	d.frames = frames
+	line := statementLine(statement)
+	file := statementFile(statement)
+
+	if !d.shouldPause(file, line, len(frames)) {
+		return
+	}
+
+	fmt.Fprintf(d.writer, "stopped at %s\n", d.location(file, line))
+	d.printWatches()
+	d.repl()
+}
+
+func (d *Debugger) AfterStatement(statement Statement, frames []CallFrame) {
+	d.frames = frames
+}
+
+func (d *Debugger) shouldPause(file *File, line uint, depth int) bool {
+	if d.breakpoints[fileName(file)][int(line)] {
+		return true
+	}
+
+	switch d.stepping {
+	case stepNext:
+		return depth <= d.baseDepth
+	case stepIn:
+		return true
+	case stepOut:
+		return depth < d.baseDepth
+	default:
+		return false
+	}
+}
+
+// repl answers commands typed at the terminal while paused, until a
+// step/next/continue command resumes execution.
+func (d *Debugger) repl() {
+	for {
+		command, args, ok := d.nextCommand()
+		if !ok {
+			return
+		}
+
+		switch command {
+		case "step", "s":
+			d.stepping = stepIn
+			return
+		case "next", "n":
+			d.stepping = stepNext
+			d.baseDepth = len(d.frames)
+			return
+		case "continue", "c":
+			d.stepping = stepNone
+			return
+		case "break", "b":
+			d.addBreakpoint(args)
+		case "bt":
+			d.printBacktrace()
+		case "locals":
+			d.printLocals()
+		case "watch":
+			d.addWatch(args)
+		case "disas":
+			d.disas()
+		default:
+			fmt.Fprintf(d.writer, "unknown command %q (step, next, continue, break <file>:<line>, bt, locals, watch <name>, disas)\n", command)
+		}
+	}
+}
+
+func (d *Debugger) nextCommand() (command string, args string, ok bool) {
+	fmt.Fprint(d.writer, "(dbg) ")
+	if !d.reader.Scan() {
+		return "", "", false
+	}
+
+	line := strings.TrimSpace(d.reader.Text())
+	if line == "" {
+		return d.nextCommand()
+	}
+
+	parts := strings.SplitN(line, " ", 2)
+	command = parts[0]
+	if len(parts) > 1 {
+		args = strings.TrimSpace(parts[1])
+	}
+	return command, args, true
+}
+
+// addBreakpoint parses "file:line" - the file defaults to whatever script
+// the Interpreter was constructed for if args has no colon, matching how
+// a human would expect "break 12" to mean "line 12 of the script I'm in".
+func (d *Debugger) addBreakpoint(args string) {
+	file, line := fileName(d.interpreter.File), args
+
+	if idx := strings.LastIndex(args, ":"); idx >= 0 {
+		file, line = args[:idx], args[idx+1:]
+	}
+
+	lineNumber, err := strconv.Atoi(line)
+	if err != nil {
+		fmt.Fprintln(d.writer, "usage: break <file>:<line>")
+		return
+	}
+
+	if d.breakpoints[file] == nil {
+		d.breakpoints[file] = map[int]bool{}
+	}
+	d.breakpoints[file][lineNumber] = true
+
+	fmt.Fprintf(d.writer, "breakpoint set at %s\n", d.location(NewFile(file), uint(lineNumber)))
+}
+
+func (d *Debugger) printBacktrace() {
+	if len(d.frames) == 0 {
+		fmt.Fprintln(d.writer, "#0 <script>")
+		return
+	}
+
+	for idx := len(d.frames) - 1; idx >= 0; idx-- {
+		frame := d.frames[idx]
+		fmt.Fprintf(d.writer, "#%d %s (line %d)\n", len(d.frames)-1-idx, frame.Function, frame.Line)
+	}
+}
+
+// printLocals dumps the current scope's Values, then every Enclosing
+// scope up to (and including) the global one.
+func (d *Debugger) printLocals() {
+	environment := d.interpreter.Environment
+	for depth := 0; environment != nil; depth++ {
+		label := "locals"
+		if depth > 0 {
+			label = fmt.Sprintf("enclosing[%d]", depth)
+		}
+
+		if len(environment.Values) == 0 {
+			fmt.Fprintf(d.writer, "%s: (empty)\n", label)
+		}
+		for name, value := range environment.Values {
+			fmt.Fprintf(d.writer, "%s: %s = %s\n", label, name, value.String())
+		}
+
+		environment = environment.Enclosing
+	}
+}
+
+func (d *Debugger) addWatch(name string) {
+	if name == "" {
+		fmt.Fprintln(d.writer, "usage: watch <name>")
+		return
+	}
+	d.watches = append(d.watches, name)
+	fmt.Fprintf(d.writer, "watching %q\n", name)
+}
+
+func (d *Debugger) printWatches() {
+	for _, name := range d.watches {
+		if value, ok := d.lookup(name); ok {
+			fmt.Fprintf(d.writer, "watch: %s = %s\n", name, value.String())
+		} else {
+			fmt.Fprintf(d.writer, "watch: %s = <undefined>\n", name)
+		}
+	}
+}
+
+func (d *Debugger) lookup(name string) (*Value, bool) {
+	for environment := d.interpreter.Environment; environment != nil; environment = environment.Enclosing {
+		if value, ok := environment.Values[name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (d *Debugger) disas() {
+	if d.chunk == nil {
+		fmt.Fprintln(d.writer, "disas is only available with --backend=vm")
+		return
+	}
+	NewDisassembler(d.writer).Disassemble(d.chunk, d.chunkName)
+}
+
+func (d *Debugger) location(file *File, line uint) string {
+	if name := fileName(file); name != "" {
+		return fmt.Sprintf("%s:%d", name, line)
+	}
+	return fmt.Sprintf("line %d", line)
+}
+
+func fileName(file *File) string {
+	if file == nil {
+		return ""
+	}
+	return file.Name
+}
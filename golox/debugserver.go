@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// debugRequest and debugResponse are a minimal, DAP-inspired request/response
+// pair exchanged as newline-delimited JSON over stdio. This isn't a full
+// implementation of the Debug Adapter Protocol (no Content-Length framed
+// messages, no launch/initialize handshake) - it covers the subset an editor
+// extension needs to drive line breakpoints and step through a script:
+// setBreakpoints, continue, next, stepIn, stepOut, stackTrace, variables,
+// and evaluate.
+type debugRequest struct {
+	Command    string `json:"command"`
+	Lines      []uint `json:"lines,omitempty"`
+	FrameIndex int    `json:"frameIndex,omitempty"`
+	Expression string `json:"expression,omitempty"`
+}
+
+type debugResponse struct {
+	Event   string      `json:"event,omitempty"`
+	Success bool        `json:"success"`
+	Body    interface{} `json:"body,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+type debugStackFrame struct {
+	Function string `json:"function"`
+	Line     uint   `json:"line"`
+}
+
+const (
+	stepNone = ""
+	stepNext = "next"
+	stepIn   = "stepIn"
+	stepOut  = "stepOut"
+)
+
+// DebugServer is a Tracer that pauses the interpreter at breakpoints and
+// single-step requests, and answers stack/variable/evaluate queries while
+// paused. Runtime cost when no DebugServer is attached is a nil check
+// before and after each statement in Interpreter.execute, since Tracer is
+// an optional field.
+type DebugServer struct {
+	interpreter *Interpreter
+
+	reader *bufio.Scanner
+	writer io.Writer
+
+	breakpoints map[uint]bool
+
+	frames    []CallFrame
+	stepping  string
+	baseDepth int
+}
+
+// NewDebugServer wires a DebugServer to read requests from in and write
+// responses to out, typically os.Stdin/os.Stdout when run over stdio. It
+// starts in stepIn mode, so the script pauses before its first statement
+// and the client can set breakpoints before sending continue.
+func NewDebugServer(interpreter *Interpreter, in io.Reader, out io.Writer) *DebugServer {
+	server := &DebugServer{
+		interpreter: interpreter,
+		reader:      bufio.NewScanner(in),
+		writer:      out,
+		breakpoints: map[uint]bool{},
+		stepping:    stepIn,
+	}
+	interpreter.Tracer = server
+	return server
+}
+
+// Run executes the given source under the debugger, blocking on stdio
+// requests until the program finishes.
+func (d *DebugServer) Run(source string) error {
+	value, err := d.interpreter.Run(source)
+	d.send(debugResponse{Event: "terminated"})
+
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		d.send(debugResponse{Event: "output", Body: value.String()})
+	}
+	return nil
+}
+
+func (d *DebugServer) BeforeStatement(statement Statement, frames []CallFrame) {
+	d.frames = frames
+	line := statementLine(statement)
+
+	if !d.shouldPause(line, len(frames)) {
+		return
+	}
+
+	d.send(debugResponse{Event: "stopped", Body: map[string]interface{}{"line": line}})
+	d.pause()
+}
+
+func (d *DebugServer) AfterStatement(statement Statement, frames []CallFrame) {
+	d.frames = frames
+}
+
+func (d *DebugServer) shouldPause(line uint, depth int) bool {
+	if d.breakpoints[line] {
+		return true
+	}
+
+	switch d.stepping {
+	case stepNext:
+		return depth <= d.baseDepth
+	case stepIn:
+		return true
+	case stepOut:
+		return depth < d.baseDepth
+	default:
+		return false
+	}
+}
+
+// pause blocks the interpreter goroutine, answering stack/variables/evaluate
+// requests in place, until a continue/next/stepIn/stepOut request resumes
+// execution.
+func (d *DebugServer) pause() {
+	for {
+		request, ok := d.nextRequest()
+		if !ok {
+			return
+		}
+
+		switch request.Command {
+		case "setBreakpoints":
+			d.setBreakpoints(request.Lines)
+			d.send(debugResponse{Success: true})
+		case "stackTrace":
+			d.send(debugResponse{Success: true, Body: d.stackTrace()})
+		case "variables":
+			d.send(debugResponse{Success: true, Body: d.variables(request.FrameIndex)})
+		case "evaluate":
+			result, err := d.evaluate(request.Expression)
+			if err != nil {
+				d.send(debugResponse{Message: err.Error()})
+			} else {
+				d.send(debugResponse{Success: true, Body: result})
+			}
+		case "continue":
+			d.stepping = stepNone
+			return
+		case "next":
+			d.stepping = stepNext
+			d.baseDepth = len(d.frames)
+			return
+		case "stepIn":
+			d.stepping = stepIn
+			return
+		case "stepOut":
+			d.stepping = stepOut
+			d.baseDepth = len(d.frames)
+			return
+		default:
+			d.send(debugResponse{Message: fmt.Sprintf("unknown command '%s'", request.Command)})
+		}
+	}
+}
+
+func (d *DebugServer) nextRequest() (debugRequest, bool) {
+	if !d.reader.Scan() {
+		return debugRequest{}, false
+	}
+
+	var request debugRequest
+	if err := json.Unmarshal(d.reader.Bytes(), &request); err != nil {
+		d.send(debugResponse{Message: err.Error()})
+		return d.nextRequest()
+	}
+	return request, true
+}
+
+func (d *DebugServer) send(response debugResponse) {
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(d.writer, string(bytes))
+}
+
+func (d *DebugServer) setBreakpoints(lines []uint) {
+	d.breakpoints = map[uint]bool{}
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+func (d *DebugServer) stackTrace() []debugStackFrame {
+	trace := make([]debugStackFrame, len(d.frames))
+	for idx, frame := range d.frames {
+		trace[len(d.frames)-1-idx] = debugStackFrame{Function: frame.Function, Line: frame.Line}
+	}
+	return trace
+}
+
+// variables walks the Environment chain for the requested frame (0 is the
+// innermost), flattening Enclosing scopes into a single name->value map the
+// way a DAP "scopes" + "variables" pair would, without exposing the chain
+// itself.
+func (d *DebugServer) variables(frameIndex int) map[string]string {
+	result := map[string]string{}
+
+	var environment *Environment
+	if frameIndex >= 0 && frameIndex < len(d.frames) {
+		environment = d.frames[len(d.frames)-1-frameIndex].Environment
+	} else {
+		environment = d.interpreter.Environment
+	}
+
+	for environment != nil {
+		for name, value := range environment.Values {
+			if _, exists := result[name]; !exists {
+				result[name] = value.String()
+			}
+		}
+		environment = environment.Enclosing
+	}
+
+	return result
+}
+
+func (d *DebugServer) evaluate(source string) (string, error) {
+	scanner := NewScanner(source, d.interpreter.File, d.interpreter.Diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, d.interpreter.Diagnostics)
+	expression, err := parser.expression()
+	if err != nil {
+		return "", err
+	}
+
+	value, err := d.interpreter.evaluate(expression)
+	if err != nil {
+		return "", err
+	}
+	return value.String(), nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity distinguishes a Diagnostic that should fail the run from one
+// that's merely informational. SeverityWarning doesn't fail a run -
+// HasErrors/HasRuntimeErrors both skip it - it just rides along in Format's
+// output; UnusedVariableChecker and ConstantFolder (ast_checks.go) are its
+// first users.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+// Phase records which pass raised a Diagnostic, so a host inspecting a
+// Diagnostics collection (or main.run deciding an exit code) can tell a
+// static error from a runtime one without string-matching the message.
+type Phase int
+
+const (
+	PhaseIO Phase = iota
+	PhaseScan
+	PhaseParse
+	PhaseResolve
+	PhaseTypeCheck
+	PhaseCompile
+	PhaseRuntime
+)
+
+func (p Phase) String() string {
+	return [...]string{"io", "scan", "parse", "resolve", "typecheck", "compile", "runtime"}[p]
+}
+
+// Diagnostic is one structured problem raised by a pass. Token is nil for
+// a Diagnostic that isn't anchored to a specific token (a bad --load-ast
+// path, an unterminated string the scanner never finished a token for).
+// AddLine still sets Line/Column/File for such a Diagnostic so Format can
+// locate the offending source line; one added through Add with a nil Token
+// has none of them set.
+type Diagnostic struct {
+	Phase    Phase
+	Token    *Token
+	File     *File
+	Line     uint
+	Column   uint
+	Message  string
+	Hint     string
+	Severity Severity
+}
+
+func (d Diagnostic) line() uint {
+	if d.Token != nil {
+		return d.Token.Line
+	}
+	return d.Line
+}
+
+func (d Diagnostic) column() uint {
+	if d.Token != nil {
+		return d.Token.Column
+	}
+	return d.Column
+}
+
+// file returns the File this Diagnostic should be reported against,
+// preferring the anchoring Token's (a module re-scanned by ModuleLoader
+// carries its own File, distinct from whatever File the importing pass is
+// using) over the explicit File passed to AddLine.
+func (d Diagnostic) file() *File {
+	if d.Token != nil && d.Token.File != nil {
+		return d.Token.File
+	}
+	return d.File
+}
+
+func (d Diagnostic) where() string {
+	if d.Token == nil {
+		return ""
+	}
+	if d.Token.Type == EOF {
+		return " at end"
+	}
+	return fmt.Sprintf(" at '%s'", d.Token.Lexeme)
+}
+
+func (d Diagnostic) label() string {
+	if d.Severity == SeverityWarning {
+		return "Warning"
+	}
+	return "Error"
+}
+
+// header renders a Diagnostic's first line. With a known File it's
+// "name:line:col: Error at 'x': message", editor/LSP-friendly and
+// unambiguous across imported modules; without one (a synthesized token,
+// or none at all) it falls back to the historical "[line N] Error ...".
+func (d Diagnostic) header() string {
+	message := d.Message
+	if d.Hint != "" {
+		message = fmt.Sprintf("%s (%s)", message, d.Hint)
+	}
+
+	if file := d.file(); file != nil {
+		return fmt.Sprintf("%s:%d:%d: %s%s: %s", file.Name, d.line(), d.column(), d.label(), d.where(), message)
+	}
+	return fmt.Sprintf("[line %d] %s%s: %s", d.line(), d.label(), d.where(), message)
+}
+
+// Diagnostics collects every Diagnostic raised across a run. It replaces
+// the package-level hadError/hadRuntimeError booleans golox used to carry
+// instead: Scanner, Parser, the PegParser, and Interpreter each take one
+// through their constructor (Resolver, TypeChecker, and Compiler reach it
+// through the Interpreter they're already given) and append to it, so a
+// host embedding golox as a library - see Interpreter.Run in host.go - can
+// inspect the results of a run instead of reading mutable global state.
+type Diagnostics struct {
+	Entries []Diagnostic
+}
+
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{}
+}
+
+// Add records a Diagnostic anchored to token (nil if there isn't one).
+func (d *Diagnostics) Add(phase Phase, token *Token, message string) {
+	d.Entries = append(d.Entries, Diagnostic{Phase: phase, Token: token, Message: message, Severity: SeverityError})
+}
+
+// AddHint is Add with a suggested fix attached, rendered in the formatted
+// output as a "(hint)" suffix on the message.
+func (d *Diagnostics) AddHint(phase Phase, token *Token, message string, hint string) {
+	d.Entries = append(d.Entries, Diagnostic{Phase: phase, Token: token, Message: message, Hint: hint, Severity: SeverityError})
+}
+
+// AddWarning is AddHint at SeverityWarning instead of SeverityError - for a
+// pass that has something worth telling the user about without failing
+// their run over it.
+func (d *Diagnostics) AddWarning(phase Phase, token *Token, message string, hint string) {
+	d.Entries = append(d.Entries, Diagnostic{Phase: phase, Token: token, Message: message, Hint: hint, Severity: SeverityWarning})
+}
+
+// AddLine records a Diagnostic anchored to a source line/column rather
+// than a full token, for problems the scanner finds before (or instead
+// of) producing one - e.g. an unterminated string, reported against the
+// line/column of its opening quote. file is nil for passes (the PEG
+// parser today) that don't yet track which File they're scanning.
+func (d *Diagnostics) AddLine(phase Phase, file *File, line uint, column uint, message string) {
+	d.Entries = append(d.Entries, Diagnostic{Phase: phase, File: file, Line: line, Column: column, Message: message, Severity: SeverityError})
+}
+
+// AddRuntimeError records err (as raised by the tree-walk Interpreter or
+// the VM) as a PhaseRuntime Diagnostic.
+func (d *Diagnostics) AddRuntimeError(err error) {
+	if runtimeErr, ok := err.(*RuntimeError); ok {
+		d.Entries = append(d.Entries, Diagnostic{Phase: PhaseRuntime, Token: runtimeErr.Token, Message: runtimeErr.Message, Severity: SeverityError})
+		return
+	}
+
+	d.Entries = append(d.Entries, Diagnostic{Phase: PhaseRuntime, Message: err.Error(), Severity: SeverityError})
+}
+
+// HasErrors reports whether any pass other than the Interpreter/VM raised
+// a SeverityError Diagnostic - what main.run checks, instead of the old
+// hadError global, to decide whether to keep going to the next phase.
+func (d *Diagnostics) HasErrors() bool {
+	for _, entry := range d.Entries {
+		if entry.Phase != PhaseRuntime && entry.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRuntimeErrors reports whether the Interpreter or VM raised a
+// PhaseRuntime Diagnostic - what runFile checks, instead of the old
+// hadRuntimeError global, to decide whether to exit(70).
+func (d *Diagnostics) HasRuntimeErrors() bool {
+	for _, entry := range d.Entries {
+		if entry.Phase == PhaseRuntime && entry.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Format renders every collected Diagnostic against source: its header
+// line, followed by the offending source line and a caret range under the
+// bad token, the same shape a human reading golox's old stderr output
+// would recognize - except the underline now spans the token's full
+// Offset..Offset+Length instead of a single '^' when that's known.
+func (d *Diagnostics) Format(source string) string {
+	lines := strings.Split(source, "\n")
+
+	var b strings.Builder
+	for _, entry := range d.Entries {
+		b.WriteString(entry.header())
+		b.WriteString("\n")
+
+		lineNumber := entry.line()
+		if lineNumber == 0 || int(lineNumber) > len(lines) {
+			continue
+		}
+
+		sourceLine := lines[lineNumber-1]
+		b.WriteString(sourceLine)
+		b.WriteString("\n")
+
+		column, length := 0, 1
+		if entry.Token != nil && entry.Token.Column > 0 {
+			column = int(entry.Token.Column) - 1
+			length = int(entry.Token.Length)
+		} else if entry.Token != nil {
+			if idx := strings.Index(sourceLine, entry.Token.Lexeme); idx >= 0 {
+				column = idx
+			}
+			length = len(entry.Token.Lexeme)
+		} else if entry.Column > 0 {
+			column = int(entry.Column) - 1
+		}
+		if length < 1 {
+			length = 1
+		}
+
+		b.WriteString(strings.Repeat(" ", column))
+		b.WriteString(strings.Repeat("^", length))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
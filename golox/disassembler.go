@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Disassembler prints a Chunk's bytecode in clox's textual format - one
+// line per instruction, its offset, source line (or "|" when it's the same
+// line as the instruction before it), and decoded operands - to help
+// debug the compiler and VM without a debugger attached.
+type Disassembler struct {
+	Output io.Writer
+}
+
+func NewDisassembler(output io.Writer) *Disassembler {
+	return &Disassembler{Output: output}
+}
+
+// Disassemble prints every instruction in chunk under a "== name =="
+// header, then recurses into any BytecodeFunction constants (one function
+// body per OpClosure) so a whole program's bytecode is visible from a
+// single top-level call.
+func (d *Disassembler) Disassemble(chunk *Chunk, name string) {
+	fmt.Fprintf(d.Output, "== %s ==\n", name)
+
+	for offset := 0; offset < len(chunk.Code); {
+		offset = d.instruction(chunk, offset)
+	}
+
+	for _, constant := range chunk.Constants {
+		if constant.Type == ValueTypeBytecodeFunction {
+			d.Disassemble(constant.BytecodeFunctionValue.Chunk, constant.BytecodeFunctionValue.String())
+		}
+	}
+}
+
+func (d *Disassembler) instruction(chunk *Chunk, offset int) int {
+	fmt.Fprintf(d.Output, "%04d ", offset)
+
+	if offset > 0 && chunk.Lines[offset] == chunk.Lines[offset-1] {
+		fmt.Fprint(d.Output, "   | ")
+	} else {
+		fmt.Fprintf(d.Output, "%4d ", chunk.Lines[offset])
+	}
+
+	op := OpCode(chunk.Code[offset])
+	switch op {
+	case OpConstant:
+		return d.constantInstruction(chunk, op, offset)
+	case OpGetLocal, OpSetLocal, OpGetUpvalue, OpSetUpvalue, OpCall:
+		return d.byteInstruction(chunk, op, offset)
+	case OpGetGlobal, OpDefineGlobal, OpSetGlobal, OpGetProperty, OpSetProperty, OpClass, OpMethod:
+		return d.constantInstruction(chunk, op, offset)
+	case OpJump, OpJumpIfFalse:
+		return d.jumpInstruction(chunk, op, 1, offset)
+	case OpLoop:
+		return d.jumpInstruction(chunk, op, -1, offset)
+	case OpClosure:
+		return d.closureInstruction(chunk, offset)
+	default:
+		fmt.Fprintf(d.Output, "%s\n", op)
+		return offset + 1
+	}
+}
+
+func (d *Disassembler) constantInstruction(chunk *Chunk, op OpCode, offset int) int {
+	index := chunk.Code[offset+1]
+	fmt.Fprintf(d.Output, "%-16s %4d '%s'\n", op, index, chunk.Constants[index].String())
+	return offset + 2
+}
+
+func (d *Disassembler) byteInstruction(chunk *Chunk, op OpCode, offset int) int {
+	slot := chunk.Code[offset+1]
+	fmt.Fprintf(d.Output, "%-16s %4d\n", op, slot)
+	return offset + 2
+}
+
+func (d *Disassembler) jumpInstruction(chunk *Chunk, op OpCode, sign int, offset int) int {
+	jump := int(chunk.ReadUint16(offset + 1))
+	fmt.Fprintf(d.Output, "%-16s %4d -> %d\n", op, offset, offset+3+sign*jump)
+	return offset + 3
+}
+
+// closureInstruction decodes OpClosure's constant-pool index plus the
+// (isLocal, index) pair that follows per upvalue, mirroring what VM.run's
+// OpClosure case itself reads.
+func (d *Disassembler) closureInstruction(chunk *Chunk, offset int) int {
+	index := chunk.Code[offset+1]
+	constant := chunk.Constants[index]
+	fmt.Fprintf(d.Output, "%-16s %4d '%s'\n", OpClosure, index, constant.String())
+	offset += 2
+
+	if constant.Type == ValueTypeBytecodeFunction {
+		for i := 0; i < constant.BytecodeFunctionValue.UpvalueCount; i++ {
+			isLocal := chunk.Code[offset]
+			upvalueIndex := chunk.Code[offset+1]
+			kind := "upvalue"
+			if isLocal == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(d.Output, "%04d      |                     %s %d\n", offset, kind, upvalueIndex)
+			offset += 2
+		}
+	}
+
+	return offset
+}
@@ -176,6 +176,62 @@ func (e *VariableExpression) AcceptValue(visitor ExpressionVisitor[Value]) (Valu
 	return visitor.VisitVariableExpression(e)
 }
 
+type ListExpression struct {
+	Bracket  *Token
+	Elements []Expression
+}
+
+func (e *ListExpression) AcceptString(visitor ExpressionVisitor[string]) (string, error) {
+	return visitor.VisitListExpression(e)
+}
+
+func (e *ListExpression) AcceptValue(visitor ExpressionVisitor[Value]) (Value, error) {
+	return visitor.VisitListExpression(e)
+}
+
+type MapExpression struct {
+	Brace  *Token
+	Keys   []Expression
+	Values []Expression
+}
+
+func (e *MapExpression) AcceptString(visitor ExpressionVisitor[string]) (string, error) {
+	return visitor.VisitMapExpression(e)
+}
+
+func (e *MapExpression) AcceptValue(visitor ExpressionVisitor[Value]) (Value, error) {
+	return visitor.VisitMapExpression(e)
+}
+
+type IndexExpression struct {
+	Object  Expression
+	Bracket *Token
+	Index   Expression
+}
+
+func (e *IndexExpression) AcceptString(visitor ExpressionVisitor[string]) (string, error) {
+	return visitor.VisitIndexExpression(e)
+}
+
+func (e *IndexExpression) AcceptValue(visitor ExpressionVisitor[Value]) (Value, error) {
+	return visitor.VisitIndexExpression(e)
+}
+
+type IndexSetExpression struct {
+	Object  Expression
+	Bracket *Token
+	Index   Expression
+	Value   Expression
+}
+
+func (e *IndexSetExpression) AcceptString(visitor ExpressionVisitor[string]) (string, error) {
+	return visitor.VisitIndexSetExpression(e)
+}
+
+func (e *IndexSetExpression) AcceptValue(visitor ExpressionVisitor[Value]) (Value, error) {
+	return visitor.VisitIndexSetExpression(e)
+}
+
 type ExpressionVisitorConstraint interface {
 	string | Value
 }
@@ -194,4 +250,8 @@ type ExpressionVisitor[T ExpressionVisitorConstraint] interface {
 	VisitTernaryExpression(expression *TernaryExpression) (T, error)
 	VisitUnaryExpression(expression *UnaryExpression) (T, error)
 	VisitVariableExpression(expression *VariableExpression) (T, error)
+	VisitListExpression(expression *ListExpression) (T, error)
+	VisitMapExpression(expression *MapExpression) (T, error)
+	VisitIndexExpression(expression *IndexExpression) (T, error)
+	VisitIndexSetExpression(expression *IndexSetExpression) (T, error)
 }
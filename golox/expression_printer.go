@@ -84,6 +84,23 @@ func (p *ExpressionPrinter) VisitVariableExpression(expression *VariableExpressi
 	return expression.Name.Lexeme, nil
 }
 
+func (p *ExpressionPrinter) VisitListExpression(expression *ListExpression) (string, error) {
+	return p.parenthesize("list", expression.Elements...)
+}
+
+func (p *ExpressionPrinter) VisitMapExpression(expression *MapExpression) (string, error) {
+	expressions := append(append([]Expression{}, expression.Keys...), expression.Values...)
+	return p.parenthesize("map", expressions...)
+}
+
+func (p *ExpressionPrinter) VisitIndexExpression(expression *IndexExpression) (string, error) {
+	return p.parenthesize("index", expression.Object, expression.Index)
+}
+
+func (p *ExpressionPrinter) VisitIndexSetExpression(expression *IndexSetExpression) (string, error) {
+	return p.parenthesize("index=", expression.Object, expression.Index, expression.Value)
+}
+
 func (p *ExpressionPrinter) parenthesize(name string, expressions ...Expression) (string, error) {
 	builder := strings.Builder{}
 
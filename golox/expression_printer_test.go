@@ -38,3 +38,33 @@ func TestExpressionPrinter(t *testing.T) {
 		t.Fatalf("Print failed - expected %s, got %s", expectedResult, result)
 	}
 }
+
+// TestExpressionPrinterIndex covers ExpressionPrinter's output for
+// IndexExpression over a ListExpression - the list/map/subscript feature
+// itself (ValueTypeList, ListExpression, IndexExpression, and friends)
+// was already added in chunk0-2; this only backfills the printer case
+// chunk0-2 left untested. See list_test.go for the interpreter-level
+// get/set/out-of-bounds behavior that was missing alongside it.
+func TestExpressionPrinterIndex(t *testing.T) {
+	// [1, 2][0]
+	expectedResult := "(index (list 1 2) 0)"
+
+	expression := &IndexExpression{
+		Object: &ListExpression{
+			Elements: []Expression{
+				&LiteralExpression{Value: NewNumberLiteral(1)},
+				&LiteralExpression{Value: NewNumberLiteral(2)},
+			},
+		},
+		Index: &LiteralExpression{Value: NewNumberLiteral(0)},
+	}
+
+	result, err := (&ExpressionPrinter{}).Print(expression)
+	if err != nil {
+		t.Fatalf("Print failed: %s", err)
+	}
+
+	if result != expectedResult {
+		t.Fatalf("Print failed - expected %s, got %s", expectedResult, result)
+	}
+}
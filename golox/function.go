@@ -30,6 +30,15 @@ func (f *LoxFunction) Call(interpreter *Interpreter, arguments []*Value) (value
 		environment.Define(param.Lexeme, arguments[idx])
 	}
 
+	interpreter.Frames = append(interpreter.Frames, CallFrame{
+		Function:    f.Name(),
+		Line:        f.Declaration.Name.Line,
+		Environment: environment,
+	})
+	defer func() {
+		interpreter.Frames = interpreter.Frames[:len(interpreter.Frames)-1]
+	}()
+
 	value, err = interpreter.executeBlock(f.Declaration.Body, environment)
 	if err != nil {
 		// returns are passed through errors
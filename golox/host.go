@@ -0,0 +1,114 @@
+package main
+
+import "fmt"
+
+// Run scans, parses, resolves, type-checks, and interprets source against
+// an already-constructed Interpreter, returning the value produced by the
+// last top-level statement it executed (nil when that statement produces
+// none, e.g. a print). It's the entry point a host Go
+// program uses to embed a Lox script, as an alternative to main.run - since
+// i.Diagnostics belongs to this Interpreter rather than a package-level
+// global, nothing here needs the CLI's error-reporting state.
+func (i *Interpreter) Run(source string) (*Value, error) {
+	scanner := NewScanner(source, i.File, i.Diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, i.Debug, i.Diagnostics)
+	// i.Diagnostics already has every error Parse's returned error would
+	// describe - checked right below.
+	statements, _ := parser.Parse()
+	if i.Diagnostics.HasErrors() {
+		return nil, fmt.Errorf("parse error: %s", i.Diagnostics.Format(source))
+	}
+
+	resolver := NewResolver(i)
+	statements = resolver.Resolve(statements)
+	if i.Diagnostics.HasErrors() {
+		return nil, fmt.Errorf("resolve error: %s", i.Diagnostics.Format(source))
+	}
+
+	typeChecker := NewTypeChecker(i)
+	typeChecker.Check(statements)
+	if i.Diagnostics.HasErrors() {
+		return nil, fmt.Errorf("type error: %s", i.Diagnostics.Format(source))
+	}
+
+	value := i.Interpret(statements)
+	if i.Diagnostics.HasRuntimeErrors() {
+		return nil, fmt.Errorf("runtime error: %s", i.Diagnostics.Format(source))
+	}
+
+	return value, nil
+}
+
+// ToGoValue marshals a Lox Value into a plain Go interface{}, so host code
+// can consume return values without touching the Value union directly.
+func ToGoValue(value *Value) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch value.Type {
+	case ValueTypeNil:
+		return nil
+	case ValueTypeNumber:
+		return value.NumberValue
+	case ValueTypeString:
+		return value.StringValue
+	case ValueTypeBool:
+		return value.BoolValue
+	case ValueTypeList:
+		elements := make([]interface{}, len(value.ListValue.Elements))
+		for idx, element := range value.ListValue.Elements {
+			elements[idx] = ToGoValue(element)
+		}
+		return elements
+	case ValueTypeMap:
+		entries := make(map[string]interface{}, len(value.MapValue.Entries))
+		for key, entry := range value.MapValue.Entries {
+			entries[key] = ToGoValue(entry)
+		}
+		return entries
+	default:
+		return value.String()
+	}
+}
+
+// FromGoValue marshals a plain Go value into a Lox Value, so host code can
+// pass arguments into RegisterNative callbacks or script globals.
+func FromGoValue(value interface{}) (Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return NewNilValue(), nil
+	case float64:
+		return NewNumberValue(v), nil
+	case int:
+		return NewNumberValue(float64(v)), nil
+	case string:
+		return NewStringValue(v), nil
+	case bool:
+		return NewBoolValue(v), nil
+	case []interface{}:
+		elements := make([]*Value, len(v))
+		for idx, element := range v {
+			converted, err := FromGoValue(element)
+			if err != nil {
+				return Value{}, err
+			}
+			elements[idx] = &converted
+		}
+		return NewListValue(NewLoxList(elements)), nil
+	case map[string]interface{}:
+		entries := make(map[string]*Value, len(v))
+		for key, entry := range v {
+			converted, err := FromGoValue(entry)
+			if err != nil {
+				return Value{}, err
+			}
+			entries[key] = &converted
+		}
+		return NewMapValue(NewLoxMap(entries)), nil
+	default:
+		return Value{}, fmt.Errorf("unsupported Go type %T", value)
+	}
+}
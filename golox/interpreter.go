@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
 )
 
 type RuntimeError struct {
@@ -28,6 +30,12 @@ func (e *ReturnError) Unwrap() error {
 // fake error to communicate a break
 type BreakError struct {
 	*RuntimeError
+
+	// Label is the target loop's label, or "" for an unlabeled break -
+	// see WhileStatement.Label. A loop only catches a labeled BreakError
+	// that names it; anything else it lets propagate to whichever
+	// enclosing loop does.
+	Label string
 }
 
 func (e *BreakError) Unwrap() error {
@@ -37,6 +45,10 @@ func (e *BreakError) Unwrap() error {
 // fake error to communicate a continue
 type ContinueError struct {
 	*RuntimeError
+
+	// Label is the target loop's label, or "" for an unlabeled continue -
+	// see BreakError.Label.
+	Label string
 }
 
 func (e *ContinueError) Unwrap() error {
@@ -52,14 +64,59 @@ type Interpreter struct {
 	// to each Visit() method, letting the stack handle block scope cleanup
 	Environment *Environment `json:"environment"`
 
+	// NextStatement is the index, within the most recent slice passed to
+	// Interpret, of the statement that hasn't completed yet - everything
+	// before it already ran. Snapshot saves it so a caller resuming from
+	// LoadSnapshot knows where to pick back up: re-parse the source and
+	// call Interpret(statements[NextStatement:]).
+	NextStatement int `json:"nextStatement"`
+
+	// Output is where `print` and the native println functions write to,
+	// so a host embedding the interpreter can capture script output
+	// instead of it going straight to os.Stdout.
+	Output io.Writer `json:"-"`
+
+	// Frames is the live call stack, maintained by LoxFunction.Call, so a
+	// Tracer can report a stack trace and walk each frame's Environment.
+	Frames []CallFrame `json:"-"`
+
+	// Tracer fires before/after each statement if set. It's nil by
+	// default, so a program run without a debugger attached pays only the
+	// cost of a nil check before and after each statement.
+	Tracer Tracer `json:"-"`
+
+	// Loader resolves `import` statements that aren't a stdlib module name
+	// to a file on disk. It's nil when there's no script directory to
+	// resolve relative paths against (e.g. the REPL), in which case only
+	// stdlib imports are available.
+	Loader *ModuleLoader `json:"-"`
+
+	// Diagnostics collects every problem raised while scanning, parsing,
+	// resolving, type-checking, compiling, or running a program - shared
+	// with those passes instead of the package-level hadError/
+	// hadRuntimeError booleans golox used to carry, so embedding golox as
+	// a library (see Interpreter.Run in host.go) doesn't require reading
+	// mutable global state to learn whether a run failed.
+	Diagnostics *Diagnostics `json:"-"`
+
+	// File identifies the script this Interpreter is running, so it can be
+	// passed to any Scanner it constructs internally (Interpreter.Run,
+	// DebugServer.evaluate) without each caller inventing a name. A
+	// ModuleLoader import uses the imported file's own File instead, since
+	// that's a different source than the one driving the import.
+	File *File `json:"-"`
+
 	Debug bool `json:"debug"`
 }
 
-func NewInterpreter(debug bool) Interpreter {
+func NewInterpreter(debug bool, file *File) Interpreter {
 	i := Interpreter{
-		Locals:  map[Expression]int{},
-		Globals: NewEnvironment(),
-		Debug:   debug,
+		Locals:      map[Expression]int{},
+		Diagnostics: NewDiagnostics(),
+		File:        file,
+		Globals:     NewEnvironment(),
+		Output:      os.Stdout,
+		Debug:       debug,
 	}
 
 	DefineNativeFunctions(i.Globals)
@@ -68,19 +125,32 @@ func NewInterpreter(debug bool) Interpreter {
 	return i
 }
 
+// RegisterNative installs a host-provided function into the interpreter's
+// global environment, so a Go program embedding the interpreter can expose
+// callbacks that scripts call like any other native function. Pass
+// variadic true (arity is then ignored by callers, but still documents the
+// minimum/typical count) to accept any number of arguments, the way the
+// stdlib's `io.printf` does.
+func (i *Interpreter) RegisterNative(name string, arity int, variadic bool, fn func(interpreter *Interpreter, arguments []*Value) (*Value, error)) {
+	value := NewFunctionValue(NewNativeFunction(name, arity, variadic, fn))
+	i.Globals.Define(name, &value)
+}
+
 func (i *Interpreter) Interpret(statements []Statement) (value *Value) {
 	if i.Debug {
 		fmt.Println("Running interpreter ...")
 	}
 
-	for _, statement := range statements {
+	for idx, statement := range statements {
+		i.NextStatement = idx
 		v, err := i.execute(statement)
 		if err != nil {
-			runtimeError(err)
+			i.Diagnostics.AddRuntimeError(err)
 			return nil
 		}
 		value = v
 	}
+	i.NextStatement = len(statements)
 
 	return
 }
@@ -114,7 +184,7 @@ func (i *Interpreter) VisitPrintStatement(statement *PrintStatement) (value *Val
 		return
 	}
 
-	fmt.Println(v)
+	fmt.Fprintln(i.Output, v)
 
 	// no return value here
 	// because it looks weird to print things twice
@@ -179,6 +249,8 @@ func (i *Interpreter) VisitVarStatement(statement *VarStatement) (value *Value,
 }
 
 func (i *Interpreter) VisitWhileStatement(statement *WhileStatement) (value *Value, err error) {
+	label := tokenName(statement.Label)
+
 	for {
 		condition, innerErr := i.evaluate(statement.Condition)
 		if innerErr != nil {
@@ -192,12 +264,20 @@ func (i *Interpreter) VisitWhileStatement(statement *WhileStatement) (value *Val
 
 		_, innerErr = i.execute(statement.Body)
 		if innerErr != nil {
-			if _, ok := innerErr.(*BreakError); ok {
-				break
+			if breakErr, ok := innerErr.(*BreakError); ok {
+				if breakErr.Label == "" || breakErr.Label == label {
+					break
+				}
+				err = innerErr
+				return
 			}
 
-			if _, ok := innerErr.(*ContinueError); ok {
-				continue
+			if continueErr, ok := innerErr.(*ContinueError); ok {
+				if continueErr.Label == "" || continueErr.Label == label {
+					continue
+				}
+				err = innerErr
+				return
 			}
 
 			err = innerErr
@@ -214,6 +294,7 @@ func (i *Interpreter) VisitBreakStatement(statement *BreakStatement) (value *Val
 			Message: "Break only supported in loops.",
 			Token:   statement.Keyword,
 		},
+		Label: tokenName(statement.Label),
 	}
 	return
 }
@@ -224,6 +305,7 @@ func (i *Interpreter) VisitContinueStatement(statement *ContinueStatement) (valu
 			Message: "Continue only supported in loops.",
 			Token:   statement.Keyword,
 		},
+		Label: tokenName(statement.Label),
 	}
 	return
 }
@@ -278,8 +360,47 @@ func (i *Interpreter) VisitClassStatement(statement *ClassStatement) (value *Val
 	return
 }
 
+func (i *Interpreter) VisitImportStatement(statement *ImportStatement) (value *Value, err error) {
+	name := statement.Path.Literal.StringValue
+
+	module, ok := StdlibModules()[name]
+	if !ok {
+		if i.Loader == nil {
+			err = &RuntimeError{
+				Message: fmt.Sprintf("Unknown module '%s'.", name),
+				Token:   statement.Path,
+			}
+			return
+		}
+
+		module, err = i.Loader.Load(name, statement.Path)
+		if err != nil {
+			return
+		}
+	}
+
+	binding := defaultModuleBinding(name)
+	if statement.Alias != nil {
+		binding = statement.Alias.Lexeme
+	}
+
+	v := NewModuleValue(module)
+	i.Environment.Define(binding, &v)
+	return
+}
+
 func (i *Interpreter) execute(statement Statement) (*Value, error) {
-	return statement.Accept(i)
+	if i.Tracer != nil {
+		i.Tracer.BeforeStatement(statement, i.Frames)
+	}
+
+	value, err := statement.Accept(i)
+
+	if i.Tracer != nil {
+		i.Tracer.AfterStatement(statement, i.Frames)
+	}
+
+	return value, err
 }
 
 func (i *Interpreter) executeBlock(statements []Statement, environment *Environment) (value *Value, err error) {
@@ -308,7 +429,14 @@ func (i *Interpreter) VisitAssignExpression(expression *AssignExpression) (value
 	if distance, ok := i.Locals[expression]; ok {
 		i.Environment.AssignAt(distance, expression.Name, &value)
 	} else {
-		err = i.Globals.Assign(expression.Name, &value)
+		// Unresolved means the resolver found no tracked scope for this
+		// name, so walk i.Environment's own Enclosing chain instead of
+		// jumping straight to Globals: for the main script i.Environment
+		// *is* Globals, but a file module runs its top level in a child
+		// Environment (see ModuleLoader.Load), and an unresolved name
+		// there still needs to reach that module's own top-level bindings
+		// before it falls all the way back to Globals.
+		err = i.Environment.Assign(expression.Name, &value)
 		if err != nil {
 			return
 		}
@@ -502,7 +630,7 @@ func (i *Interpreter) VisitCallExpression(expression *CallExpression) (value Val
 	}
 
 	argumentCount := len(arguments)
-	if argumentCount != callable.Arity() {
+	if callable.Arity() != -1 && argumentCount != callable.Arity() {
 		err = &RuntimeError{
 			//Message: fmt.Sprintf("'%s' expected %d arguments but got %d.", callable.Name(), callable.Arity(), argumentCount),
 			Message: fmt.Sprintf("Expected %d arguments but got %d.", callable.Arity(), argumentCount),
@@ -533,6 +661,33 @@ func (i *Interpreter) VisitGetExpression(expression *GetExpression) (value Value
 		return
 	}
 
+	if object.Type == ValueTypeModule {
+		v, innerErr := object.ModuleValue.Get(expression.Name)
+		if innerErr != nil {
+			err = innerErr
+			return
+		}
+		return *v, nil
+	}
+
+	if object.Type == ValueTypeList {
+		v, innerErr := object.ListValue.Get(expression.Name)
+		if innerErr != nil {
+			err = innerErr
+			return
+		}
+		return *v, nil
+	}
+
+	if object.Type == ValueTypeMap {
+		v, innerErr := object.MapValue.Get(expression.Name)
+		if innerErr != nil {
+			err = innerErr
+			return
+		}
+		return *v, nil
+	}
+
 	if object.Type != ValueTypeInstance {
 		err = &RuntimeError{
 			Message: "Only instances have properties.",
@@ -616,13 +771,205 @@ func (i *Interpreter) VisitLiteralExpression(expression *LiteralExpression) (Val
 	return NewValue(expression.Value)
 }
 
+func (i *Interpreter) VisitListExpression(expression *ListExpression) (value Value, err error) {
+	elements := make([]*Value, len(expression.Elements))
+	for idx, element := range expression.Elements {
+		v, innerErr := i.evaluate(element)
+		if innerErr != nil {
+			err = innerErr
+			return
+		}
+		elements[idx] = &v
+	}
+
+	value = NewListValue(NewLoxList(elements))
+	return
+}
+
+func (i *Interpreter) VisitMapExpression(expression *MapExpression) (value Value, err error) {
+	entries := map[string]*Value{}
+	for idx, keyExpr := range expression.Keys {
+		key, innerErr := i.evaluate(keyExpr)
+		if innerErr != nil {
+			err = innerErr
+			return
+		}
+
+		if key.Type != ValueTypeString {
+			err = &RuntimeError{
+				Message: "Map keys must be strings.",
+				Token:   expression.Brace,
+			}
+			return
+		}
+
+		v, innerErr := i.evaluate(expression.Values[idx])
+		if innerErr != nil {
+			err = innerErr
+			return
+		}
+
+		entries[key.StringValue] = &v
+	}
+
+	value = NewMapValue(NewLoxMap(entries))
+	return
+}
+
+func (i *Interpreter) VisitIndexExpression(expression *IndexExpression) (value Value, err error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return
+	}
+
+	index, err := i.evaluate(expression.Index)
+	if err != nil {
+		return
+	}
+
+	switch object.Type {
+	case ValueTypeList:
+		if index.Type != ValueTypeNumber {
+			err = &RuntimeError{Message: "List index must be a number.", Token: expression.Bracket}
+			return
+		}
+
+		idx := int(index.NumberValue)
+		if idx < 0 || idx >= len(object.ListValue.Elements) {
+			err = &RuntimeError{Message: "List index out of bounds.", Token: expression.Bracket}
+			return
+		}
+
+		value = *object.ListValue.Elements[idx]
+	case ValueTypeMap:
+		if index.Type != ValueTypeString {
+			err = &RuntimeError{Message: "Map key must be a string.", Token: expression.Bracket}
+			return
+		}
+
+		v, ok := object.MapValue.Entries[index.StringValue]
+		if !ok {
+			err = &RuntimeError{Message: fmt.Sprintf("Undefined map key '%s'.", index.StringValue), Token: expression.Bracket}
+			return
+		}
+
+		value = *v
+	default:
+		err = &RuntimeError{Message: "Only lists and maps support indexing.", Token: expression.Bracket}
+	}
+
+	return
+}
+
+func (i *Interpreter) VisitIndexSetExpression(expression *IndexSetExpression) (value Value, err error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return
+	}
+
+	index, err := i.evaluate(expression.Index)
+	if err != nil {
+		return
+	}
+
+	value, err = i.evaluate(expression.Value)
+	if err != nil {
+		return
+	}
+
+	switch object.Type {
+	case ValueTypeList:
+		if index.Type != ValueTypeNumber {
+			err = &RuntimeError{Message: "List index must be a number.", Token: expression.Bracket}
+			return
+		}
+
+		idx := int(index.NumberValue)
+		if idx < 0 || idx >= len(object.ListValue.Elements) {
+			err = &RuntimeError{Message: "List index out of bounds.", Token: expression.Bracket}
+			return
+		}
+
+		object.ListValue.Elements[idx] = &value
+	case ValueTypeMap:
+		if index.Type != ValueTypeString {
+			err = &RuntimeError{Message: "Map key must be a string.", Token: expression.Bracket}
+			return
+		}
+
+		object.MapValue.Entries[index.StringValue] = &value
+	default:
+		err = &RuntimeError{Message: "Only lists and maps support indexed assignment.", Token: expression.Bracket}
+	}
+
+	return
+}
+
+func (i *Interpreter) VisitForeachStatement(statement *ForeachStatement) (value *Value, err error) {
+	iterable, err := i.evaluate(statement.Iterable)
+	if err != nil {
+		return
+	}
+
+	var items []*Value
+	switch iterable.Type {
+	case ValueTypeList:
+		items = iterable.ListValue.Elements
+	case ValueTypeMap:
+		items = make([]*Value, 0, len(iterable.MapValue.Entries))
+		for key := range iterable.MapValue.Entries {
+			v := NewStringValue(key)
+			items = append(items, &v)
+		}
+	default:
+		err = &RuntimeError{
+			Message: "Can only iterate over lists and maps.",
+			Token:   statement.Keyword,
+		}
+		return
+	}
+
+	for _, item := range items {
+		environment := NewEnvironmentScope(i.Environment)
+		environment.Define(statement.Name.Lexeme, item)
+
+		_, innerErr := i.executeBlock([]Statement{statement.Body}, environment)
+		if innerErr != nil {
+			// foreach has no label of its own, so it only ever catches an
+			// unlabeled break/continue - a labeled one always targets some
+			// other loop and must keep propagating outward to find it.
+			if breakErr, ok := innerErr.(*BreakError); ok && breakErr.Label == "" {
+				break
+			}
+
+			if continueErr, ok := innerErr.(*ContinueError); ok && continueErr.Label == "" {
+				continue
+			}
+
+			err = innerErr
+			return
+		}
+	}
+
+	return
+}
+
 func (i *Interpreter) lookUpVariable(name *Token, expression Expression) (value *Value, err error) {
 	if distance, ok := i.Locals[expression]; ok {
 		value = i.Environment.GetAt(distance, name.Lexeme)
 		return
 	}
 
-	value, err = i.Globals.Get(name)
+	// Unresolved means the resolver found no tracked scope for this name
+	// (see Resolver.resolveLocal's "assume global" fallback), so walk
+	// i.Environment's own Enclosing chain instead of jumping straight to
+	// Globals: for the main script i.Environment *is* Globals, but a file
+	// module runs its top level in a child Environment (see
+	// ModuleLoader.Load), and an unresolved name there still needs to
+	// reach that module's own top-level bindings - e.g. one function
+	// calling a sibling, or a later var reading an earlier one - before it
+	// falls all the way back to Globals.
+	value, err = i.Environment.Get(name)
 	if err != nil {
 		return
 	}
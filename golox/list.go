@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LoxList is a first-class, mutable, ordered collection of Values.
+// It's held behind a pointer so that methods like push/pop are visible
+// to every Value referencing the same list.
+type LoxList struct {
+	Elements []*Value `json:"elements"`
+}
+
+func NewLoxList(elements []*Value) *LoxList {
+	return &LoxList{
+		Elements: elements,
+	}
+}
+
+func (l LoxList) String() string {
+	parts := make([]string, len(l.Elements))
+	for idx, element := range l.Elements {
+		parts[idx] = element.String()
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, ", "))
+}
+
+func (l *LoxList) Get(name *Token) (value *Value, err error) {
+	switch name.Lexeme {
+	case "push":
+		v := NewFunctionValue(NewNativeFunction("push", 1, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			l.Elements = append(l.Elements, arguments[0])
+			return nil, nil
+		}))
+		value = &v
+	case "pop":
+		v := NewFunctionValue(NewNativeFunction("pop", 0, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			if len(l.Elements) == 0 {
+				return nil, &RuntimeError{Message: "Can't pop from an empty list.", Token: name}
+			}
+			last := l.Elements[len(l.Elements)-1]
+			l.Elements = l.Elements[:len(l.Elements)-1]
+			return last, nil
+		}))
+		value = &v
+	case "length":
+		v := NewFunctionValue(NewNativeFunction("length", 0, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			length := NewNumberValue(float64(len(l.Elements)))
+			return &length, nil
+		}))
+		value = &v
+	case "contains":
+		v := NewFunctionValue(NewNativeFunction("contains", 1, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			for _, element := range l.Elements {
+				if element.Equals(arguments[0]) {
+					result := NewBoolValue(true)
+					return &result, nil
+				}
+			}
+			result := NewBoolValue(false)
+			return &result, nil
+		}))
+		value = &v
+	default:
+		err = &RuntimeError{
+			Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+			Token:   name,
+		}
+	}
+	return
+}
+
+// LoxMap is a first-class, mutable map of string keys to Values.
+type LoxMap struct {
+	Entries map[string]*Value `json:"entries"`
+}
+
+func NewLoxMap(entries map[string]*Value) *LoxMap {
+	return &LoxMap{
+		Entries: entries,
+	}
+}
+
+func (m LoxMap) String() string {
+	parts := make([]string, 0, len(m.Entries))
+	for key, value := range m.Entries {
+		parts = append(parts, fmt.Sprintf("%q: %s", key, value.String()))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(parts, ", "))
+}
+
+func (m *LoxMap) Get(name *Token) (value *Value, err error) {
+	switch name.Lexeme {
+	case "keys":
+		v := NewFunctionValue(NewNativeFunction("keys", 0, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			keys := make([]*Value, 0, len(m.Entries))
+			for key := range m.Entries {
+				v := NewStringValue(key)
+				keys = append(keys, &v)
+			}
+			result := NewListValue(NewLoxList(keys))
+			return &result, nil
+		}))
+		value = &v
+	case "values":
+		v := NewFunctionValue(NewNativeFunction("values", 0, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			values := make([]*Value, 0, len(m.Entries))
+			for _, entry := range m.Entries {
+				values = append(values, entry)
+			}
+			result := NewListValue(NewLoxList(values))
+			return &result, nil
+		}))
+		value = &v
+	case "length":
+		v := NewFunctionValue(NewNativeFunction("length", 0, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			length := NewNumberValue(float64(len(m.Entries)))
+			return &length, nil
+		}))
+		value = &v
+	case "contains":
+		v := NewFunctionValue(NewNativeFunction("contains", 1, false, func(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+			if arguments[0].Type != ValueTypeString {
+				return nil, &RuntimeError{Message: "Map keys must be strings.", Token: name}
+			}
+			_, ok := m.Entries[arguments[0].StringValue]
+			result := NewBoolValue(ok)
+			return &result, nil
+		}))
+		value = &v
+	default:
+		err = &RuntimeError{
+			Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+			Token:   name,
+		}
+	}
+	return
+}
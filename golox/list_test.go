@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// interpretProgram scans, parses, resolves, and interprets source,
+// returning its captured print output and the Interpreter's Diagnostics
+// for callers that expect a runtime error.
+func interpretProgram(t *testing.T, source string) (string, *Diagnostics) {
+	t.Helper()
+
+	interpreter := NewInterpreter(false, nil)
+
+	var output bytes.Buffer
+	interpreter.Output = &output
+
+	scanner := NewScanner(source, interpreter.File, interpreter.Diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, interpreter.Diagnostics)
+	statements, _ := parser.Parse()
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	resolver := NewResolver(&interpreter)
+	statements = resolver.Resolve(statements)
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("resolve failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	interpreter.Interpret(statements)
+
+	return output.String(), interpreter.Diagnostics
+}
+
+func TestListSubscriptGet(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+var a = [10, 20, 30];
+print a[1];
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "20\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+func TestListSubscriptSet(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+var a = [10, 20, 30];
+a[1] = 99;
+print a[0];
+print a[1];
+print a[2];
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "10\n99\n30\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+func TestListSubscriptOutOfBounds(t *testing.T) {
+	_, diagnostics := interpretProgram(t, `
+var a = [1, 2, 3];
+print a[5];
+`)
+	if !diagnostics.HasRuntimeErrors() {
+		t.Fatalf("expected a runtime error for an out-of-bounds index, got none")
+	}
+}
+
+func TestListSubscriptNegativeIndexOutOfBounds(t *testing.T) {
+	_, diagnostics := interpretProgram(t, `
+var a = [1, 2, 3];
+print a[-1];
+`)
+	if !diagnostics.HasRuntimeErrors() {
+		t.Fatalf("expected a runtime error for a negative index, got none")
+	}
+}
+
+func TestMapSubscriptGetAndSet(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+var m = {"a": 1};
+m["b"] = 2;
+print m["a"];
+print m["b"];
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "1\n2\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+func TestMapSubscriptUndefinedKey(t *testing.T) {
+	_, diagnostics := interpretProgram(t, `
+var m = {"a": 1};
+print m["missing"];
+`)
+	if !diagnostics.HasRuntimeErrors() {
+		t.Fatalf("expected a runtime error for an undefined map key, got none")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+// TestBreak covers a plain, unlabeled break unwinding out of its
+// immediately enclosing loop - it couldn't even scan until chunk3-3's
+// fix taught the scanner "break"/"continue" as keywords rather than
+// identifiers.
+func TestBreak(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+var i = 0;
+while (i < 5) {
+	if (i == 2) break;
+	print i;
+	i = i + 1;
+}
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "0\n1\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+// TestLabeledContinue covers a labeled continue reaching past an inner
+// loop to the outer loop it names, per WhileStatement.Label.
+func TestLabeledContinue(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+var i = 0;
+outer: while (i < 3) {
+	var j = 0;
+	while (j < 3) {
+		if (j == 1) {
+			i = i + 1;
+			continue outer;
+		}
+		print i;
+		print j;
+		j = j + 1;
+	}
+	i = i + 1;
+}
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "0\n0\n1\n0\n2\n0\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+// TestLabeledBreak covers a labeled break unwinding straight past a
+// nested loop to the outer loop it names, rather than just the nearest
+// enclosing one.
+func TestLabeledBreak(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+outer: while (true) {
+	while (true) {
+		break outer;
+	}
+	print "unreachable";
+}
+print "after";
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "after\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
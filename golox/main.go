@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 )
@@ -13,12 +14,38 @@ import (
 func main() {
 	debug := flag.Bool("debug", false, "Enable debug output")
 	profile := flag.Bool("profile", false, "Enable profiling")
+	emitAst := flag.String("emit-ast", "", "Write the parsed AST as JSON to the given file")
+	loadAst := flag.String("load-ast", "", "Skip scanning/parsing and load the AST as JSON from the given file")
+	parserKind := flag.String("parser", "recursive", "Front end to parse with: recursive or peg")
+	pegGrammar := flag.String("peg-grammar", "lox.peg", "PEG grammar file used by --parser=peg")
+	backend := flag.String("backend", "tree", "Execution backend: tree (tree-walking Interpreter) or vm (bytecode Compiler+VM)")
+	disassemble := flag.Bool("disassemble", false, "With --backend=vm, print the compiled bytecode instead of running it")
+	debugRepl := flag.Bool("debug-repl", false, "Pause before each statement in an interactive debugger (step/next/continue/break/bt/locals/watch/disas) - see debugger.go")
 	// TODO: a 'strict' flag would be useful for passing the lox test harness
 
 	flag.Parse()
 
+	if len(flag.Args()) == 2 && flag.Args()[0] == "debug" {
+		if err := runDebugServer(flag.Args()[1], *debug); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(flag.Args()) > 1 {
 		fmt.Println("Usage: golox [script]")
+		fmt.Println("       golox debug [script]")
+		os.Exit(64)
+	}
+
+	if *parserKind != "recursive" && *parserKind != "peg" {
+		fmt.Printf("Unknown --parser %q, expected recursive or peg\n", *parserKind)
+		os.Exit(64)
+	}
+
+	if *backend != "tree" && *backend != "vm" {
+		fmt.Printf("Unknown --backend %q, expected tree or vm\n", *backend)
 		os.Exit(64)
 	}
 
@@ -35,9 +62,11 @@ func main() {
 
 	var err error
 	if len(flag.Args()) == 1 {
-		err = runFile(flag.Args()[0], *debug)
+		err = runFile(flag.Args()[0], *debug, *debugRepl, *emitAst, *loadAst, *parserKind, *pegGrammar, *backend, *disassemble)
+	} else if *loadAst != "" {
+		err = runFile("", *debug, *debugRepl, *emitAst, *loadAst, *parserKind, *pegGrammar, *backend, *disassemble)
 	} else {
-		err = runPrompt(*debug)
+		err = runPrompt(*debug, *parserKind, *pegGrammar, *backend, *disassemble)
 	}
 
 	if err != nil {
@@ -65,29 +94,69 @@ func main() {
 	}
 }
 
-func runFile(filename string, debug bool) (err error) {
-	bytes, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return
+// runFile runs a script from disk. If loadAstPath is set, filename may be
+// empty - the AST is loaded from loadAstPath instead of being scanned and
+// parsed from the script's source. If debugRepl is set, a Debugger is
+// attached so the script pauses before its first statement - see
+// debugger.go.
+func runFile(filename string, debug bool, debugRepl bool, emitAstPath string, loadAstPath string, parserKind string, pegGrammar string, backend string, disassemble bool) (err error) {
+	var source string
+	baseDir := "."
+	if filename != "" {
+		bytes, readErr := ioutil.ReadFile(filename)
+		if readErr != nil {
+			return readErr
+		}
+		source = string(bytes)
+		baseDir = filepath.Dir(filename)
+	}
+
+	fileName := filename
+	if fileName == "" {
+		fileName = "<ast>"
 	}
 
-	interpreter := NewInterpreter(debug)
+	interpreter := NewInterpreter(debug, NewFile(fileName))
+	interpreter.Loader = NewModuleLoader(&interpreter, baseDir)
 
-	run(&interpreter, string(bytes), false, debug)
+	var debugger *Debugger
+	if debugRepl {
+		debugger = NewDebugger(&interpreter, os.Stdin, os.Stdout)
+	}
 
-	if hadError {
+	run(&interpreter, debugger, source, false, debug, emitAstPath, loadAstPath, parserKind, pegGrammar, backend, disassemble)
+
+	if interpreter.Diagnostics.HasErrors() {
+		fmt.Print(interpreter.Diagnostics.Format(source))
 		os.Exit(65)
 	}
 
-	if hadRuntimeError {
+	if interpreter.Diagnostics.HasRuntimeErrors() {
+		fmt.Print(interpreter.Diagnostics.Format(source))
 		os.Exit(70)
 	}
 
 	return
 }
 
-func runPrompt(debug bool) (err error) {
-	interpreter := NewInterpreter(debug)
+// runDebugServer starts a DebugServer speaking its request/response protocol
+// over stdin/stdout, so an editor can attach, set breakpoints, and step
+// through the script instead of it just running to completion.
+func runDebugServer(filename string, debug bool) (err error) {
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	interpreter := NewInterpreter(debug, NewFile(filename))
+	interpreter.Loader = NewModuleLoader(&interpreter, filepath.Dir(filename))
+
+	server := NewDebugServer(&interpreter, os.Stdin, os.Stdout)
+	return server.Run(string(bytes))
+}
+
+func runPrompt(debug bool, parserKind string, pegGrammar string, backend string, disassemble bool) (err error) {
+	interpreter := NewInterpreter(debug, NewFile("<stdin>"))
 
 	scanner := bufio.NewScanner(os.Stdin)
 	for {
@@ -98,31 +167,135 @@ func runPrompt(debug bool) (err error) {
 		}
 
 		line := scanner.Text()
-		run(&interpreter, line, true, debug)
+		interpreter.Diagnostics = NewDiagnostics()
+		run(&interpreter, nil, line, true, debug, "", "", parserKind, pegGrammar, backend, disassemble)
 
-		hadError = false
-		hadRuntimeError = false
+		if len(interpreter.Diagnostics.Entries) > 0 {
+			fmt.Print(interpreter.Diagnostics.Format(line))
+		}
 	}
 
 }
 
-func run(interpreter *Interpreter, source string, printExpressions bool, debug bool) {
-	scanner := NewScanner(source, debug)
-	scanner.ScanTokens()
+// run executes source (ignored when loadAstPath is set) against interpreter.
+// emitAstPath, if set, writes the parsed AST as JSON before execution
+// continues; loadAstPath, if set, loads the AST as JSON instead of scanning
+// and parsing source at all. parserKind selects the front end used to parse
+// source ("recursive" or "peg", the latter compiling pegGrammar) - see
+// peg_parser.go. backend selects how the resolved AST is executed ("tree"
+// walks it directly with interpreter, "vm" compiles it to bytecode and
+// runs that on a VM instead - see compiler.go/vm.go); with backend "vm",
+// disassemble prints the compiled Chunk rather than running it. debugger,
+// if non-nil, is already wired as interpreter.Tracer by the caller (see
+// runFile) and additionally gets a look at the compiled Chunk under
+// backend "vm", where Tracer never fires - see Debugger.Inspect.
+func run(interpreter *Interpreter, debugger *Debugger, source string, printExpressions bool, debug bool, emitAstPath string, loadAstPath string, parserKind string, pegGrammar string, backend string, disassemble bool) {
+	var statements []Statement
 
-	//fmt.Println(scanner.Tokens)
+	if loadAstPath != "" {
+		data, err := ioutil.ReadFile(loadAstPath)
+		if err != nil {
+			interpreter.Diagnostics.Add(PhaseIO, nil, err.Error())
+			return
+		}
 
-	parser := NewParser(scanner.Tokens, debug)
-	statements := parser.Parse()
+		statements, err = UnmarshalStatements(data)
+		if err != nil {
+			interpreter.Diagnostics.Add(PhaseIO, nil, err.Error())
+			return
+		}
+	} else if parserKind == "peg" {
+		pegParser, err := NewPegParser(pegGrammar)
+		if err != nil {
+			interpreter.Diagnostics.Add(PhaseIO, nil, err.Error())
+			return
+		}
 
-	if hadError {
-		return
+		statements = pegParser.Parse(source, interpreter.Diagnostics)
+
+		if interpreter.Diagnostics.HasErrors() {
+			return
+		}
+	} else {
+		scanner := NewScanner(source, interpreter.File, interpreter.Diagnostics)
+		scanner.ScanTokens()
+
+		//fmt.Println(scanner.Tokens)
+
+		parser := NewParser(scanner.Tokens, debug, interpreter.Diagnostics)
+		// Diagnostics already has every error Parse's returned error would
+		// describe - checked right below - so there's nothing more to do
+		// with it here.
+		statements, _ = parser.Parse()
+
+		if interpreter.Diagnostics.HasErrors() {
+			return
+		}
 	}
 
+	if emitAstPath != "" {
+		data, err := MarshalStatements(statements)
+		if err != nil {
+			interpreter.Diagnostics.Add(PhaseIO, nil, err.Error())
+			return
+		}
+
+		if err := ioutil.WriteFile(emitAstPath, data, 0644); err != nil {
+			interpreter.Diagnostics.Add(PhaseIO, nil, err.Error())
+			return
+		}
+	}
+
+	// Optimization/desugaring passes run before the resolver, not after:
+	// they rebuild every node they touch, so running them on an
+	// already-resolved tree would leave the resolver's Locals map pointing
+	// at expression nodes that no longer exist.
+	statements = ConstantFold(statements)
+	statements = DesugarTernary(statements)
+
 	resolver := NewResolver(interpreter)
-	resolver.Resolve(statements)
+	statements = resolver.Resolve(statements)
+
+	if interpreter.Diagnostics.HasErrors() {
+		return
+	}
+
+	// Both of these only ever add SeverityWarning diagnostics, so there's
+	// nothing to check before moving on - see ast_checks.go.
+	NewUnusedVariableChecker(interpreter.Diagnostics).Check(statements)
+	NewConstantFolder(interpreter.Diagnostics).Check(statements)
+
+	typeChecker := NewTypeChecker(interpreter)
+	typeChecker.Check(statements)
+
+	if interpreter.Diagnostics.HasErrors() {
+		return
+	}
+
+	if backend == "vm" {
+		function := Compile(interpreter, statements)
+		if interpreter.Diagnostics.HasErrors() {
+			return
+		}
+
+		if disassemble {
+			NewDisassembler(interpreter.Output).Disassemble(function.Chunk, function.String())
+			return
+		}
+
+		if debugger != nil {
+			debugger.SetChunk(function.Chunk, function.String())
+			debugger.Inspect()
+		}
 
-	if hadError {
+		value, err := NewVM(interpreter).Interpret(function)
+		if err != nil {
+			interpreter.Diagnostics.AddRuntimeError(err)
+			return
+		}
+		if printExpressions && value != nil {
+			fmt.Println(value.String())
+		}
 		return
 	}
 
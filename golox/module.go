@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+type ModuleFunctions map[string]*NativeFunction
+
+type Module struct {
+	ModuleName string          `json:"name"`
+	Functions  ModuleFunctions `json:"functions,omitempty"`
+
+	// Values holds the top-level bindings of a file module loaded by a
+	// ModuleLoader. Stdlib modules leave this nil and use Functions instead.
+	Values Values `json:"values,omitempty"`
+}
+
+func NewModule(name string, functions ModuleFunctions) *Module {
+	return &Module{
+		ModuleName: name,
+		Functions:  functions,
+	}
+}
+
+// NewFileModule wraps a file module's top-level Environment bindings, once
+// a ModuleLoader has finished executing its statements.
+func NewFileModule(name string, values Values) *Module {
+	return &Module{
+		ModuleName: name,
+		Values:     values,
+	}
+}
+
+func (m Module) String() string {
+	return fmt.Sprintf("<module %s>", m.ModuleName)
+}
+
+func (m *Module) Get(name *Token) (value *Value, err error) {
+	if function, ok := m.Functions[name.Lexeme]; ok {
+		v := NewFunctionValue(function)
+		value = &v
+		return
+	}
+
+	if v, ok := m.Values[name.Lexeme]; ok {
+		value = v
+		return
+	}
+
+	err = &RuntimeError{
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+		Token:   name,
+	}
+	return
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleLoader resolves `import` paths that aren't a stdlib module name
+// against the directory the loader was created with (the main script's,
+// also for an import made from inside a module), then the LOX_PATH search list,
+// and loads each file exactly once: scanned, parsed, resolved, and its
+// top-level statements run in a fresh child Environment. Modules are
+// cached by their canonicalized path, so re-imports are cheap and importing
+// a module that's still loading (a cycle) is reported with the chain that
+// caused it instead of recursing forever.
+type ModuleLoader struct {
+	interpreter *Interpreter
+	baseDir     string
+
+	cache   map[string]*Module
+	loading []string
+}
+
+func NewModuleLoader(interpreter *Interpreter, baseDir string) *ModuleLoader {
+	return &ModuleLoader{
+		interpreter: interpreter,
+		baseDir:     baseDir,
+		cache:       map[string]*Module{},
+	}
+}
+
+func (l *ModuleLoader) resolve(path string) (string, error) {
+	candidates := []string{filepath.Join(l.baseDir, path)}
+
+	if loxPath := os.Getenv("LOX_PATH"); loxPath != "" {
+		for _, dir := range strings.Split(loxPath, string(os.PathListSeparator)) {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return filepath.Abs(candidate)
+		}
+	}
+
+	return "", fmt.Errorf("module '%s' not found", path)
+}
+
+// Load resolves, loads, and caches the module at path, reporting any
+// failure against importer so the error points at the `import` statement.
+func (l *ModuleLoader) Load(path string, importer *Token) (*Module, error) {
+	canonical, err := l.resolve(path)
+	if err != nil {
+		return nil, &RuntimeError{Message: err.Error(), Token: importer}
+	}
+
+	if module, ok := l.cache[canonical]; ok {
+		return module, nil
+	}
+
+	for _, loading := range l.loading {
+		if loading == canonical {
+			return nil, &RuntimeError{
+				Message: fmt.Sprintf("Cyclic import: %s -> %s.", strings.Join(l.loading, " -> "), canonical),
+				Token:   importer,
+			}
+		}
+	}
+
+	l.loading = append(l.loading, canonical)
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+	}()
+
+	source, err := ioutil.ReadFile(canonical)
+	if err != nil {
+		return nil, &RuntimeError{Message: err.Error(), Token: importer}
+	}
+
+	// diagnosticsMark lets each check below ask "did this step add a new
+	// Diagnostic?" rather than "has anything, anywhere, ever gone wrong?",
+	// since l.interpreter.Diagnostics is shared with (and already carries
+	// entries from) whatever scan/parse/resolve pass is importing this
+	// module in the first place.
+	diagnosticsMark := len(l.interpreter.Diagnostics.Entries)
+
+	scanner := NewScanner(string(source), NewFile(canonical), l.interpreter.Diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, l.interpreter.Debug, l.interpreter.Diagnostics)
+	// diagnosticsMark below already covers everything Parse's error return
+	// would say.
+	statements, _ := parser.Parse()
+	if len(l.interpreter.Diagnostics.Entries) > diagnosticsMark {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Failed to parse module '%s'.", path), Token: importer}
+	}
+
+	resolver := NewResolver(l.interpreter)
+	statements = resolver.Resolve(statements)
+	if len(l.interpreter.Diagnostics.Entries) > diagnosticsMark {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Failed to resolve module '%s'.", path), Token: importer}
+	}
+
+	environment := NewEnvironmentScope(l.interpreter.Globals)
+	if _, err := l.interpreter.executeBlock(statements, environment); err != nil {
+		return nil, err
+	}
+
+	module := NewFileModule(canonical, environment.Values)
+	l.cache[canonical] = module
+	return module, nil
+}
+
+// defaultModuleBinding derives the identifier an `import` binds its module
+// under when there's no `as alias`: the stdlib module name as-is, or a file
+// module's base name with its extension stripped.
+func defaultModuleBinding(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
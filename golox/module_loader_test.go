@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// runModuleScript scans, parses, resolves, and interprets source with a
+// ModuleLoader rooted at dir, returning captured print output. It mirrors
+// the pipeline main.go's run() drives, minus the PEG/VM/debug options this
+// test doesn't need.
+func runModuleScript(t *testing.T, dir string, source string) (string, *Interpreter) {
+	t.Helper()
+
+	interpreter := NewInterpreter(false, NewFile("<test>"))
+	interpreter.Loader = NewModuleLoader(&interpreter, dir)
+
+	var output bytes.Buffer
+	interpreter.Output = &output
+
+	scanner := NewScanner(source, interpreter.File, interpreter.Diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, interpreter.Diagnostics)
+	statements, _ := parser.Parse()
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	resolver := NewResolver(&interpreter)
+	statements = resolver.Resolve(statements)
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("resolve failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	interpreter.Interpret(statements)
+	if interpreter.Diagnostics.HasRuntimeErrors() {
+		t.Fatalf("interpret failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	return output.String(), &interpreter
+}
+
+// TestModuleInternalReferences covers a file module whose top-level
+// declarations refer to each other - a sibling function call and a var
+// reading an earlier var - which only runs in the module's own child
+// Environment, not Globals (see ModuleLoader.Load).
+func TestModuleInternalReferences(t *testing.T) {
+	dir := t.TempDir()
+
+	module := `
+var x = 1;
+var y = x + 1;
+
+fun square(n) { return n * n; }
+fun cube(n) { return n * square(n); }
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "mod.lox"), []byte(module), 0644); err != nil {
+		t.Fatalf("failed to write module: %s", err)
+	}
+
+	output, _ := runModuleScript(t, dir, `
+import "mod.lox" as mod;
+print mod.y;
+print mod.cube(3);
+`)
+
+	if output != "2\n27\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
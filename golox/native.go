@@ -1,12 +1,30 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
 	"time"
 )
 
 var printIsNative = false
 
+// isNativeCallable reports whether c is one of the built-in Callables
+// DefineNativeFunctions/RegisterNative install, as opposed to a script's
+// own LoxFunction/LoxClass - Snapshot uses this to skip natives instead of
+// tripping Value.MarshalJSON's "can't be serialized" error for something
+// LoadSnapshot reinstalls on every fresh Interpreter anyway.
+func isNativeCallable(c Callable) bool {
+	switch c.(type) {
+	case *ClockFunction, *PrintFunction, *NativeFunction:
+		return true
+	default:
+		return false
+	}
+}
+
 func DefineNativeFunctions(environment *Environment) {
 	// define native functions
 	clock := &ClockFunction{}
@@ -52,7 +70,7 @@ func (f *PrintFunction) Arity() int {
 }
 
 func (f *PrintFunction) Call(interpreter *Interpreter, arguments []*Value) (*Value, error) {
-	fmt.Println(arguments[0])
+	fmt.Fprintln(interpreter.Output, arguments[0])
 
 	// no return value here
 	// because it looks weird to print things twice
@@ -62,3 +80,215 @@ func (f *PrintFunction) Call(interpreter *Interpreter, arguments []*Value) (*Val
 func (f *PrintFunction) String() string {
 	return "<native fn>"
 }
+
+// NativeFunction wraps a plain Go closure as a Callable, so standard
+// library modules don't need a hand-written struct per function.
+type NativeFunction struct {
+	FunctionName string
+	ArgCount     int
+	Variadic     bool
+	Fn           func(interpreter *Interpreter, arguments []*Value) (*Value, error)
+}
+
+// NewNativeFunction builds a native function taking exactly arity
+// arguments, or any number of them when variadic is true (see Arity).
+func NewNativeFunction(name string, arity int, variadic bool, fn func(interpreter *Interpreter, arguments []*Value) (*Value, error)) *NativeFunction {
+	return &NativeFunction{
+		FunctionName: name,
+		ArgCount:     arity,
+		Variadic:     variadic,
+		Fn:           fn,
+	}
+}
+
+func (f *NativeFunction) Name() string {
+	return f.FunctionName
+}
+
+// Arity returns -1 for a variadic function, so VisitCallExpression skips
+// its argument count check and lets Fn accept any number of arguments.
+func (f *NativeFunction) Arity() int {
+	if f.Variadic {
+		return -1
+	}
+	return f.ArgCount
+}
+
+func (f *NativeFunction) Call(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	return f.Fn(interpreter, arguments)
+}
+
+func (f *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// StdlibModules returns the set of standard library modules available to
+// an `import "name";` statement, keyed by module name. Each is registered
+// as a namespace Module value, so scripts call e.g. `math.sqrt(2)` or
+// `str.split(s, ",")` off the bound name.
+func StdlibModules() map[string]*Module {
+	return map[string]*Module{
+		"math": NewModule("math", ModuleFunctions{
+			"sqrt":  NewNativeFunction("sqrt", 1, false, nativeMathSqrt),
+			"floor": NewNativeFunction("floor", 1, false, nativeMathFloor),
+		}),
+		"str": NewModule("str", ModuleFunctions{
+			"len":       NewNativeFunction("len", 1, false, nativeStrLen),
+			"substring": NewNativeFunction("substring", 3, false, nativeStrSubstring),
+			"split":     NewNativeFunction("split", 2, false, nativeStrSplit),
+		}),
+		"io": NewModule("io", ModuleFunctions{
+			"println":   NewNativeFunction("println", 1, false, nativeIoPrintln),
+			"readFile":  NewNativeFunction("readFile", 1, false, nativeIoReadFile),
+			"writeFile": NewNativeFunction("writeFile", 2, false, nativeIoWriteFile),
+			"printf":    NewNativeFunction("printf", -1, true, nativeIoPrintf),
+		}),
+		"time": NewModule("time", ModuleFunctions{
+			"now": NewNativeFunction("now", 0, false, nativeTimeNow),
+		}),
+		"json": NewModule("json", ModuleFunctions{
+			"encode": NewNativeFunction("encode", 1, false, nativeJsonEncode),
+			"decode": NewNativeFunction("decode", 1, false, nativeJsonDecode),
+		}),
+	}
+}
+
+func nativeMathSqrt(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeNumber {
+		return nil, &RuntimeError{Message: "Argument to 'sqrt' must be a number."}
+	}
+
+	value := NewNumberValue(math.Sqrt(arguments[0].NumberValue))
+	return &value, nil
+}
+
+func nativeMathFloor(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeNumber {
+		return nil, &RuntimeError{Message: "Argument to 'floor' must be a number."}
+	}
+
+	value := NewNumberValue(math.Floor(arguments[0].NumberValue))
+	return &value, nil
+}
+
+func nativeStrLen(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeString {
+		return nil, &RuntimeError{Message: "Argument to 'len' must be a string."}
+	}
+
+	value := NewNumberValue(float64(len(arguments[0].StringValue)))
+	return &value, nil
+}
+
+func nativeStrSubstring(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeString || arguments[1].Type != ValueTypeNumber || arguments[2].Type != ValueTypeNumber {
+		return nil, &RuntimeError{Message: "Invalid arguments to 'substring'."}
+	}
+
+	s := arguments[0].StringValue
+	start := int(arguments[1].NumberValue)
+	end := int(arguments[2].NumberValue)
+	if start < 0 || end > len(s) || start > end {
+		return nil, &RuntimeError{Message: "Invalid 'substring' range."}
+	}
+
+	value := NewStringValue(s[start:end])
+	return &value, nil
+}
+
+func nativeStrSplit(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeString || arguments[1].Type != ValueTypeString {
+		return nil, &RuntimeError{Message: "Invalid arguments to 'split'."}
+	}
+
+	parts := strings.Split(arguments[0].StringValue, arguments[1].StringValue)
+	elements := make([]*Value, len(parts))
+	for idx, part := range parts {
+		v := NewStringValue(part)
+		elements[idx] = &v
+	}
+
+	value := NewListValue(NewLoxList(elements))
+	return &value, nil
+}
+
+func nativeIoPrintln(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	fmt.Fprintln(interpreter.Output, arguments[0])
+	return nil, nil
+}
+
+// nativeIoPrintf is variadic: the format string is the first argument and
+// every remaining argument is substituted into it in order, the same way
+// fmt.Sprintf works with %v-style verbs.
+func nativeIoPrintf(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if len(arguments) == 0 || arguments[0].Type != ValueTypeString {
+		return nil, &RuntimeError{Message: "Argument 'format' to 'printf' must be a string."}
+	}
+
+	rest := make([]interface{}, len(arguments)-1)
+	for idx, argument := range arguments[1:] {
+		rest[idx] = argument.String()
+	}
+
+	fmt.Fprintf(interpreter.Output, arguments[0].StringValue, rest...)
+	return nil, nil
+}
+
+func nativeIoReadFile(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeString {
+		return nil, &RuntimeError{Message: "Argument to 'readFile' must be a string."}
+	}
+
+	bytes, err := ioutil.ReadFile(arguments[0].StringValue)
+	if err != nil {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Failed to read '%s': %s", arguments[0].StringValue, err.Error())}
+	}
+
+	value := NewStringValue(string(bytes))
+	return &value, nil
+}
+
+func nativeIoWriteFile(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeString || arguments[1].Type != ValueTypeString {
+		return nil, &RuntimeError{Message: "Invalid arguments to 'writeFile'."}
+	}
+
+	err := ioutil.WriteFile(arguments[0].StringValue, []byte(arguments[1].StringValue), 0644)
+	if err != nil {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Failed to write '%s': %s", arguments[0].StringValue, err.Error())}
+	}
+
+	return nil, nil
+}
+
+func nativeTimeNow(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	value := NewNumberValue(float64(time.Now().UnixMilli()) / 1000.0)
+	return &value, nil
+}
+
+func nativeJsonEncode(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	bytes, err := json.Marshal(ToGoValue(arguments[0]))
+	if err != nil {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Failed to encode JSON: %s", err.Error())}
+	}
+
+	value := NewStringValue(string(bytes))
+	return &value, nil
+}
+
+func nativeJsonDecode(interpreter *Interpreter, arguments []*Value) (*Value, error) {
+	if arguments[0].Type != ValueTypeString {
+		return nil, &RuntimeError{Message: "Argument to 'decode' must be a string."}
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(arguments[0].StringValue), &decoded); err != nil {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Failed to decode JSON: %s", err.Error())}
+	}
+
+	value, err := FromGoValue(decoded)
+	if err != nil {
+		return nil, &RuntimeError{Message: err.Error()}
+	}
+	return &value, nil
+}
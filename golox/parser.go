@@ -2,12 +2,54 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 )
 
-const (
-	MaxCallArguments = 255
-)
+// ParserConfig tunes Parser's behavior beyond what the token stream alone
+// determines - patterned after goawk's ParserConfig. NewParser builds one
+// holding this fork's historical defaults (see DefaultParserConfig);
+// NewParserWithConfig lets an embedder override them, e.g. to parse a
+// stricter dialect or guard against adversarial input.
+type ParserConfig struct {
+	// MaxCallArguments caps how many parameters a function may declare and
+	// how many arguments a call may pass - see function, finishCall.
+	MaxCallArguments int `json:"maxCallArguments"`
+
+	// AllowTrailingCommas permits a trailing ',' before the closing
+	// delimiter of a parameter list, argument list, or list/map literal.
+	AllowTrailingCommas bool `json:"allowTrailingCommas"`
+
+	// Funcs names every pre-declared native identifier (e.g. "clock", as
+	// registered by DefineNativeFunctions) so the parser can warn when a
+	// top-level var/fun declaration shadows one - see checkShadowsNative.
+	Funcs map[string]struct{} `json:"funcs,omitempty"`
+
+	// DisableComma turns off the comma operator this fork adds on top of
+	// standard Lox - see expression.
+	DisableComma bool `json:"disableComma"`
+
+	// DisableTernary turns off the `?:` ternary operator this fork adds on
+	// top of standard Lox - see initPratt, parseTernary.
+	DisableTernary bool `json:"disableTernary"`
+
+	// MaxNestingDepth caps how deeply block/finishCall/expression may
+	// recurse, guarding against pathological input (deeply nested parens,
+	// deeply nested blocks) exhausting the Go call stack before a syntax
+	// error is ever reported. Zero means unlimited - see enterNesting.
+	MaxNestingDepth int `json:"maxNestingDepth"`
+}
+
+// DefaultParserConfig is what NewParser uses: this fork's behavior from
+// before ParserConfig existed, with every toggle left at its historical
+// setting - every non-standard operator on, no nesting limit.
+func DefaultParserConfig() ParserConfig {
+	return ParserConfig{
+		MaxCallArguments: 255,
+	}
+}
 
 type FunctionKind int
 
@@ -39,40 +81,239 @@ func (e *ParserError) Error() string {
 	return e.Message
 }
 
+// position orders a ParserError by the offset of the token it's anchored
+// to, for ParserErrorList.Sort - an error with no token (shouldn't happen
+// in practice, since every error() call passes one) sorts first.
+func (e *ParserError) position() uint {
+	if len(e.Tokens) == 0 || e.Tokens[0] == nil {
+		return 0
+	}
+	return e.Tokens[0].Offset
+}
+
+// ParserErrorList collects every error Parser.error raises during one
+// Parse call, so Parse can hand its caller a single Go error instead of
+// requiring them to go look at Diagnostics - which still gets every one of
+// these too, and remains what main.run and friends actually check.
+type ParserErrorList []*ParserError
+
+func (l *ParserErrorList) Add(err *ParserError) {
+	*l = append(*l, err)
+}
+
+// Sort orders the list by source position, since errors can be added out
+// of order once synchronize starts skipping ahead past a failed
+// declaration to retry parsing later in the token stream.
+func (l ParserErrorList) Sort() {
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].position() < l[j].position()
+	})
+}
+
+// Err returns the list as an error, or nil if it's empty - the shape
+// Parse's (statements, error) return expects.
+func (l ParserErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Error formats the list as the first error's message, plus a count of
+// however many more there were - detail beyond the first error belongs in
+// Diagnostics.Format, not in a single error string.
+func (l ParserErrorList) Error() string {
+	if len(l) == 0 {
+		return ""
+	}
+	if len(l) == 1 {
+		return l[0].Message
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0].Message, len(l)-1)
+}
+
+// bailout is the panic value Parser.error raises once synchronize has
+// failed to move the parser past the same token position too many times
+// in a row - Parse's deferred recover swallows exactly this type, so a
+// parser that can't make progress stops instead of producing the same
+// error forever.
+type bailout struct{}
+
+// maxSyncAttempts bounds how many consecutive errors Parser.error will
+// tolerate at the same token position before it panics a bailout.
+const maxSyncAttempts = 10
+
 type Parser struct {
 	Tokens []*Token `json:"tokens"`
 
 	Current uint `json:"current"`
 
-	Debug bool `json:"debug"`
+	// Config tunes parsing limits and toggles - see ParserConfig.
+	Config ParserConfig `json:"config"`
+
+	// Trace enables a structured parse trace: every production method
+	// logs its name on entry and a closing ")" on exit, indented by call
+	// depth - see trace, un. Modeled on go/parser's own -trace mode.
+	Trace bool `json:"trace"`
+
+	// traceOut is where Trace output is written. Defaults to os.Stdout in
+	// NewParser; tests can point it elsewhere before calling Parse.
+	traceOut io.Writer `json:"-"`
+
+	// indent is the current parse trace nesting depth - see trace, un.
+	indent int
+
+	// nestingDepth is the current block/finishCall/expression recursion
+	// depth - see enterNesting, Config.MaxNestingDepth.
+	nestingDepth int
+
+	Diagnostics *Diagnostics `json:"-"`
+
+	// ErrorList collects every error() call made during Parse, in the
+	// order Parse's caller gets back as its error return - see
+	// ParserErrorList.
+	ErrorList ParserErrorList `json:"-"`
+
+	// syncPos/syncCount track how many consecutive errors have landed on
+	// the same token position - see bailout.
+	syncPos   uint
+	syncCount int
+
+	// prefixParseFns/infixParseFns/precedences form the Pratt parsing
+	// table - see initPratt, parsePrecedence.
+	prefixParseFns map[TokenType]prefixParseFn
+	infixParseFns  map[TokenType]infixParseFn
+	precedences    map[TokenType]Precedence
 }
 
-func NewParser(tokens []*Token, debug bool) Parser {
+func NewParser(tokens []*Token, trace bool, diagnostics *Diagnostics) Parser {
+	return NewParserWithConfig(tokens, trace, diagnostics, DefaultParserConfig())
+}
+
+// NewParserWithConfig is NewParser with an explicit ParserConfig instead
+// of this fork's defaults - see ParserConfig.
+func NewParserWithConfig(tokens []*Token, trace bool, diagnostics *Diagnostics, config ParserConfig) Parser {
 	return Parser{
-		Tokens: tokens,
-		Debug:  debug,
+		Tokens:      tokens,
+		Config:      config,
+		Trace:       trace,
+		traceOut:    os.Stdout,
+		Diagnostics: diagnostics,
+	}
+}
+
+// trace logs msg as a production method's entry, indented by the
+// parser's current nesting depth, and increments that depth. Call it via
+// `defer un(trace(p, "Name"))` at the top of a production method; un logs
+// the matching ")" and decrements the depth when that method returns. A
+// no-op unless Trace is set. Modeled on go/parser's trace/un pair.
+func trace(p *Parser, msg string) *Parser {
+	p.printTrace(msg, "(")
+	p.indent++
+	return p
+}
+
+// un closes out the entry logged by trace.
+func un(p *Parser) {
+	p.indent--
+	p.printTrace(")")
+}
+
+// printTrace writes a to traceOut, indented by p.indent and tagged with
+// the current token's source line, type, and lexeme. A no-op unless
+// Trace is set.
+func (p *Parser) printTrace(a ...interface{}) {
+	if !p.Trace {
+		return
 	}
+
+	tok := p.peek()
+	fmt.Fprintf(p.traceOut, "%5d: %s", tok.Line, strings.Repeat(". ", p.indent))
+	fmt.Fprint(p.traceOut, a...)
+	fmt.Fprintf(p.traceOut, " [%s %q]\n", tok.Type, tok.Lexeme)
+}
+
+// enterNesting bumps the parser's nesting depth and errors out once
+// Config.MaxNestingDepth is exceeded - a guard against pathologically
+// deep input (runaway "((((((" or "{{{{{{" chains) exhausting the Go call
+// stack before a syntax error is ever reported. Call at the top of
+// block/finishCall/expression, paired with a deferred call to
+// exitNesting.
+func (p *Parser) enterNesting(token *Token) error {
+	p.nestingDepth++
+	if p.Config.MaxNestingDepth > 0 && p.nestingDepth > p.Config.MaxNestingDepth {
+		return p.error(token, "Max nesting depth exceeded.")
+	}
+	return nil
 }
 
-func (p *Parser) Parse() (statements []Statement) {
-	if p.Debug {
-		fmt.Println("Parsing ...")
+// exitNesting undoes the depth increment from a matching enterNesting.
+func (p *Parser) exitNesting() {
+	p.nestingDepth--
+}
+
+// checkShadowsNative warns when a top-level var/fun declaration reuses
+// the name of a native symbol listed in Config.Funcs. Shadowing is legal
+// - natives are otherwise invisible in source, so it's usually a mistake
+// rather than something the author meant to do.
+func (p *Parser) checkShadowsNative(name *Token) {
+	if p.nestingDepth != 0 {
+		return
+	}
+
+	if _, ok := p.Config.Funcs[name.Lexeme]; !ok {
+		return
 	}
 
+	p.Diagnostics.AddWarning(PhaseParse, name, fmt.Sprintf("'%s' shadows a native function.", name.Lexeme), "")
+}
+
+// Parse parses Tokens into a program's top-level statements, recovering
+// from each declaration's parse error via synchronize so one bad
+// statement doesn't stop the rest of the file from being parsed. It
+// returns the sorted ErrorList as its error, in addition to reporting the
+// same errors through Diagnostics - see ParserErrorList.
+func (p *Parser) Parse() (statements []Statement, err error) {
+	if p.Trace {
+		fmt.Fprintln(p.traceOut, "Parsing ...")
+	}
+
+	// initPratt's registered closures bind this *Parser - deferring the
+	// call to here, rather than NewParser, matters because NewParser
+	// returns Parser by value: initializing the table there would bind
+	// the closures to NewParser's local copy, not whatever variable the
+	// caller goes on to take the address of.
+	if p.prefixParseFns == nil {
+		p.initPratt()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+		}
+
+		p.ErrorList.Sort()
+		err = p.ErrorList.Err()
+	}()
+
 	for {
 		if p.isAtEnd() {
 			break
 		}
 
-		statement := p.declaration()
-		// TODO: statement can be nil here but we aren't handling it ...
-		statements = append(statements, statement)
+		if statement := p.declaration(); statement != nil {
+			statements = append(statements, statement)
+		}
 	}
 
 	return
 }
 
 func (p *Parser) declaration() (statement Statement) {
+	defer un(trace(p, "Declaration"))
+
 	var err error
 
 	if p.match(Var) {
@@ -81,6 +322,8 @@ func (p *Parser) declaration() (statement Statement) {
 		statement, err = p.classDeclaration()
 	} else if p.match(Fun) {
 		statement, err = p.function(FunctionKindFunction)
+	} else if p.match(Import) {
+		statement, err = p.importStatement()
 	} else {
 		statement, err = p.statement()
 	}
@@ -94,11 +337,24 @@ func (p *Parser) declaration() (statement Statement) {
 }
 
 func (p *Parser) variableDeclaration() (statement Statement, err error) {
+	defer un(trace(p, "VarDecl"))
+
 	name, err := p.consume(Identifier, "Expect variable name.")
 	if err != nil {
 		return
 	}
 
+	p.checkShadowsNative(name)
+
+	// check for an optional type annotation
+	var typeAnnotation *Token
+	if p.match(Colon) {
+		typeAnnotation, err = p.consume(Identifier, "Expect type name after ':'.")
+		if err != nil {
+			return
+		}
+	}
+
 	// check for initializer
 	var initializer Expression
 	if p.match(Equal) {
@@ -117,11 +373,14 @@ func (p *Parser) variableDeclaration() (statement Statement, err error) {
 	statement = &VarStatement{
 		Name:        name,
 		Initializer: initializer,
+		Type:        typeAnnotation,
 	}
 	return
 }
 
 func (p *Parser) classDeclaration() (statement Statement, err error) {
+	defer un(trace(p, "ClassDecl"))
+
 	name, err := p.consume(Identifier, "Expect class name.")
 	if err != nil {
 		return
@@ -174,12 +433,49 @@ func (p *Parser) classDeclaration() (statement Statement, err error) {
 	return
 }
 
+func (p *Parser) importStatement() (statement Statement, err error) {
+	defer un(trace(p, "ImportDecl"))
+
+	keyword := p.previous()
+
+	path, err := p.consume(String, "Expect module path string after 'import'.")
+	if err != nil {
+		return
+	}
+
+	var alias *Token
+	if p.match(As) {
+		alias, err = p.consume(Identifier, "Expect alias name after 'as'.")
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = p.consume(Semicolon, "Expect ';' after import statement.")
+	if err != nil {
+		return
+	}
+
+	statement = &ImportStatement{
+		Keyword: keyword,
+		Path:    path,
+		Alias:   alias,
+	}
+	return
+}
+
 func (p *Parser) function(kind FunctionKind) (statement *FunctionStatement, err error) {
+	defer un(trace(p, "FunDecl"))
+
 	name, err := p.consume(Identifier, fmt.Sprintf("Expect %s name.", kind))
 	if err != nil {
 		return
 	}
 
+	if kind == FunctionKindFunction {
+		p.checkShadowsNative(name)
+	}
+
 	_, err = p.consume(LeftParen, fmt.Sprintf("Expect '(' after %s name.", kind))
 	if err != nil {
 		return
@@ -187,9 +483,10 @@ func (p *Parser) function(kind FunctionKind) (statement *FunctionStatement, err
 
 	// read parameters
 	params := []*Token{}
+	paramTypes := []*Token{}
 	if !p.check(RightParen) {
 		for {
-			if len(params) >= MaxCallArguments {
+			if len(params) >= p.Config.MaxCallArguments {
 				p.error(p.peek(), "Can't have more than 255 parameters.")
 				// don't throw the error, just report it
 			}
@@ -201,9 +498,24 @@ func (p *Parser) function(kind FunctionKind) (statement *FunctionStatement, err
 			}
 			params = append(params, param)
 
+			// check for an optional type annotation
+			var paramType *Token
+			if p.match(Colon) {
+				paramType, innerErr = p.consume(Identifier, "Expect type name after ':'.")
+				if innerErr != nil {
+					err = innerErr
+					return
+				}
+			}
+			paramTypes = append(paramTypes, paramType)
+
 			if !p.match(Comma) {
 				break
 			}
+
+			if p.Config.AllowTrailingCommas && p.check(RightParen) {
+				break
+			}
 		}
 	}
 
@@ -212,6 +524,15 @@ func (p *Parser) function(kind FunctionKind) (statement *FunctionStatement, err
 		return
 	}
 
+	// check for an optional return type annotation
+	var returnType *Token
+	if p.match(Colon) {
+		returnType, err = p.consume(Identifier, "Expect type name after ':'.")
+		if err != nil {
+			return
+		}
+	}
+
 	_, err = p.consume(LeftBrace, fmt.Sprintf("Expect '{' before %s body.", kind))
 	if err != nil {
 		return
@@ -223,16 +544,28 @@ func (p *Parser) function(kind FunctionKind) (statement *FunctionStatement, err
 	}
 
 	statement = &FunctionStatement{
-		Name:   name,
-		Params: params,
-		Body:   body,
+		Name:       name,
+		Params:     params,
+		Body:       body,
+		ParamTypes: paramTypes,
+		ReturnType: returnType,
 	}
 	return
 }
 
 func (p *Parser) statement() (statement Statement, err error) {
+	defer un(trace(p, "Stmt"))
+
+	if p.check(Identifier) && p.checkNext(Colon) {
+		return p.labeledStatement()
+	}
+
 	if p.match(For) {
-		return p.forStatement()
+		return p.forStatement(nil)
+	}
+
+	if p.match(Foreach) {
+		return p.foreachStatement()
 	}
 
 	if p.match(If) {
@@ -248,7 +581,7 @@ func (p *Parser) statement() (statement Statement, err error) {
 	}
 
 	if p.match(While) {
-		return p.whileStatement()
+		return p.whileStatement(nil)
 	}
 
 	if p.match(Break) {
@@ -275,7 +608,37 @@ func (p *Parser) statement() (statement Statement, err error) {
 	return p.expressionStatement()
 }
 
-func (p *Parser) forStatement() (statement Statement, err error) {
+// labeledStatement parses `Identifier ':' (forStatement | whileStatement)`,
+// called once statement has peeked two tokens ahead and confirmed an
+// Identifier/Colon pair precedes a loop. The label is threaded down to
+// the resulting WhileStatement - a for loop desugars to one too - so a
+// break/continue naming it can unwind straight to this loop instead of
+// the nearest enclosing one.
+func (p *Parser) labeledStatement() (statement Statement, err error) {
+	defer un(trace(p, "LabeledStmt"))
+
+	label := p.advance()
+
+	_, err = p.consume(Colon, "Expect ':' after label.")
+	if err != nil {
+		return
+	}
+
+	if p.match(For) {
+		return p.forStatement(label)
+	}
+
+	if p.match(While) {
+		return p.whileStatement(label)
+	}
+
+	err = p.error(p.peek(), "Expect 'for' or 'while' after label.")
+	return
+}
+
+func (p *Parser) forStatement(label *Token) (statement Statement, err error) {
+	defer un(trace(p, "ForStmt"))
+
 	_, err = p.consume(LeftParen, "Expect '(' after 'for'.")
 	if err != nil {
 		return
@@ -349,6 +712,7 @@ func (p *Parser) forStatement() (statement Statement, err error) {
 	statement = &WhileStatement{
 		Condition: condition,
 		Body:      statement,
+		Label:     label,
 	}
 
 	if initializer != nil {
@@ -363,7 +727,53 @@ func (p *Parser) forStatement() (statement Statement, err error) {
 	return
 }
 
+func (p *Parser) foreachStatement() (statement Statement, err error) {
+	defer un(trace(p, "ForeachStmt"))
+
+	keyword := p.previous()
+
+	_, err = p.consume(LeftParen, "Expect '(' after 'foreach'.")
+	if err != nil {
+		return
+	}
+
+	name, err := p.consume(Identifier, "Expect loop variable name.")
+	if err != nil {
+		return
+	}
+
+	_, err = p.consume(In, "Expect 'in' after loop variable name.")
+	if err != nil {
+		return
+	}
+
+	iterable, err := p.expression()
+	if err != nil {
+		return
+	}
+
+	_, err = p.consume(RightParen, "Expect ')' after foreach clauses.")
+	if err != nil {
+		return
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return
+	}
+
+	statement = &ForeachStatement{
+		Keyword:  keyword,
+		Name:     name,
+		Iterable: iterable,
+		Body:     body,
+	}
+	return
+}
+
 func (p *Parser) ifStatement() (statement Statement, err error) {
+	defer un(trace(p, "IfStmt"))
+
 	_, err = p.consume(LeftParen, "Expect '(' after 'if'.")
 	if err != nil {
 		return
@@ -401,6 +811,8 @@ func (p *Parser) ifStatement() (statement Statement, err error) {
 }
 
 func (p *Parser) printStatement() (statement Statement, err error) {
+	defer un(trace(p, "PrintStmt"))
+
 	value, err := p.expression()
 	if err != nil {
 		return
@@ -419,6 +831,8 @@ func (p *Parser) printStatement() (statement Statement, err error) {
 }
 
 func (p *Parser) returnStatement() (statement Statement, err error) {
+	defer un(trace(p, "ReturnStmt"))
+
 	keyword := p.previous()
 
 	var value Expression
@@ -442,7 +856,9 @@ func (p *Parser) returnStatement() (statement Statement, err error) {
 	return
 }
 
-func (p *Parser) whileStatement() (statement Statement, err error) {
+func (p *Parser) whileStatement(label *Token) (statement Statement, err error) {
+	defer un(trace(p, "WhileStmt"))
+
 	_, err = p.consume(LeftParen, "Expect '(' after 'while'.")
 	if err != nil {
 		return
@@ -466,13 +882,21 @@ func (p *Parser) whileStatement() (statement Statement, err error) {
 	statement = &WhileStatement{
 		Condition: condition,
 		Body:      body,
+		Label:     label,
 	}
 	return
 }
 
 func (p *Parser) breakStatement() (statement Statement, err error) {
+	defer un(trace(p, "BreakStmt"))
+
 	keyword := p.previous()
 
+	var label *Token
+	if p.check(Identifier) {
+		label = p.advance()
+	}
+
 	_, err = p.consume(Semicolon, "Expect ';' after break.")
 	//_, err = p.consumeSafe(Semicolon)
 	if err != nil {
@@ -481,13 +905,21 @@ func (p *Parser) breakStatement() (statement Statement, err error) {
 
 	statement = &BreakStatement{
 		Keyword: keyword,
+		Label:   label,
 	}
 	return
 }
 
 func (p *Parser) continueStatement() (statement Statement, err error) {
+	defer un(trace(p, "ContinueStmt"))
+
 	keyword := p.previous()
 
+	var label *Token
+	if p.check(Identifier) {
+		label = p.advance()
+	}
+
 	_, err = p.consume(Semicolon, "Expect ';' after continue.")
 	//_, err = p.consumeSafe(Semicolon)
 	if err != nil {
@@ -496,11 +928,19 @@ func (p *Parser) continueStatement() (statement Statement, err error) {
 
 	statement = &ContinueStatement{
 		Keyword: keyword,
+		Label:   label,
 	}
 	return
 }
 
 func (p *Parser) block() (statements []Statement, err error) {
+	defer un(trace(p, "Block"))
+
+	if err = p.enterNesting(p.peek()); err != nil {
+		return
+	}
+	defer p.exitNesting()
+
 	for {
 		if p.check(RightBrace) || p.isAtEnd() {
 			break
@@ -518,6 +958,8 @@ func (p *Parser) block() (statements []Statement, err error) {
 }
 
 func (p *Parser) expressionStatement() (statement Statement, err error) {
+	defer un(trace(p, "ExprStmt"))
+
 	value, err := p.expression()
 	if err != nil {
 		return
@@ -535,13 +977,27 @@ func (p *Parser) expressionStatement() (statement Statement, err error) {
 	return
 }
 
+// expression parses the full comma operator: a Pratt-parsed expression
+// (parsePrecedence below handles everything from assignment down to a
+// primary), optionally followed by `, expr` building up a right-nested
+// BinaryExpression chain. Comma isn't part of the Pratt table - it binds
+// looser than even assignment, and a few callers (call arguments, list/map
+// elements) deliberately want an expression *without* it, so they call
+// parsePrecedence(PrecedenceLowest) directly instead of going through here.
 func (p *Parser) expression() (expr Expression, err error) {
-	expr, err = p.assignment()
+	defer un(trace(p, "Expression"))
+
+	if err = p.enterNesting(p.peek()); err != nil {
+		return
+	}
+	defer p.exitNesting()
+
+	expr, err = p.parsePrecedence(PrecedenceLowest)
 	if err != nil {
 		return
 	}
 
-	if p.match(Comma) {
+	if !p.Config.DisableComma && p.match(Comma) {
 		operator := p.previous()
 
 		right, innerErr := p.expression()
@@ -560,236 +1016,395 @@ func (p *Parser) expression() (expr Expression, err error) {
 	return
 }
 
-func (p *Parser) binaryExpression(operand func() (Expression, error), tokenTypes ...TokenType) (expr Expression, err error) {
-	expr, err = operand()
-	if err != nil {
-		return
-	}
+// Precedence orders how tightly an infix operator binds, lowest first -
+// see parsePrecedence.
+type Precedence int
 
-	for {
-		if !p.match(tokenTypes...) {
-			break
-		}
+const (
+	PrecedenceLowest Precedence = iota
+	PrecedenceAssign
+	PrecedenceTernary
+	PrecedenceOr
+	PrecedenceAnd
+	PrecedenceEquality
+	PrecedenceComparison
+	PrecedenceSum
+	PrecedenceProduct
+	PrecedenceUnary
+	PrecedenceCall
+)
 
-		operator := p.previous()
+// prefixParseFn parses an expression that starts with the current token -
+// p.previous() once it's called, since parsePrecedence has already
+// consumed it. A literal, a variable reference, a unary operator, and `(`
+// (grouping) are all prefix parsers.
+type prefixParseFn func() (Expression, error)
+
+// infixParseFn parses the rest of an expression given the already-parsed
+// left operand, with the operator token as p.previous(). A binary
+// operator, `.` (property access), `[` (indexing), `(` (a call), `?`
+// (ternary), and `=` (assignment) are all infix parsers.
+type infixParseFn func(left Expression) (Expression, error)
+
+// registerPrefix installs fn as tokenType's prefix parser.
+func (p *Parser) registerPrefix(tokenType TokenType, fn prefixParseFn) {
+	p.prefixParseFns[tokenType] = fn
+}
 
-		right, innerErr := operand()
-		if innerErr != nil {
-			err = innerErr
-			return
-		}
+// registerInfix installs fn as tokenType's infix parser, binding at
+// precedence - which is also what parsePrecedence checks to decide
+// whether to keep climbing into tokenType at all.
+func (p *Parser) registerInfix(tokenType TokenType, fn infixParseFn, precedence Precedence) {
+	p.infixParseFns[tokenType] = fn
+	p.precedences[tokenType] = precedence
+}
 
-		expr = &BinaryExpression{
-			Left:     expr,
-			Operator: operator,
-			Right:    right,
-		}
+// precedenceOf is tokenType's infix binding power, or PrecedenceLowest if
+// it has none (isn't an infix operator at all, so parsePrecedence's loop
+// should stop rather than try to climb into it).
+func (p *Parser) precedenceOf(tokenType TokenType) Precedence {
+	if precedence, ok := p.precedences[tokenType]; ok {
+		return precedence
 	}
-	return
+	return PrecedenceLowest
 }
 
-func (p *Parser) assignment() (expr Expression, err error) {
-	expr, err = p.ternary()
+// initPratt registers every operator's prefix and/or infix parser, called
+// once by Parse on first use (see the prefixParseFns nil check there).
+// Adding an operator - bitwise, `%`, `**`, compound assignment - is a
+// matter of adding one registerPrefix/registerInfix call here, instead of
+// threading a new method into the old hand-written
+// equality/comparison/term/factor ladder.
+func (p *Parser) initPratt() {
+	p.prefixParseFns = map[TokenType]prefixParseFn{}
+	p.infixParseFns = map[TokenType]infixParseFn{}
+	p.precedences = map[TokenType]Precedence{}
+
+	p.registerPrefix(True, p.parseTrueLiteral)
+	p.registerPrefix(False, p.parseFalseLiteral)
+	p.registerPrefix(Nil, p.parseNilLiteral)
+	p.registerPrefix(This, p.parseThis)
+	p.registerPrefix(Super, p.parseSuper)
+	p.registerPrefix(Number, p.parseNumber)
+	p.registerPrefix(String, p.parseString)
+	p.registerPrefix(TemplateStart, p.parseString)
+	p.registerPrefix(TemplatePart, p.parseString)
+	p.registerPrefix(TemplateEnd, p.parseString)
+	p.registerPrefix(Identifier, p.parseIdentifier)
+	p.registerPrefix(LeftParen, p.parseGrouping)
+	p.registerPrefix(LeftBracket, p.listExpression)
+	p.registerPrefix(LeftBrace, p.mapExpression)
+	p.registerPrefix(Bang, p.parseUnary)
+	p.registerPrefix(Minus, p.parseUnary)
+
+	p.registerInfix(Equal, p.parseAssign, PrecedenceAssign)
+	if !p.Config.DisableTernary {
+		p.registerInfix(Question, p.parseTernary, PrecedenceTernary)
+	}
+	p.registerInfix(Or, p.makeLogicalInfix(PrecedenceOr), PrecedenceOr)
+	p.registerInfix(And, p.makeLogicalInfix(PrecedenceAnd), PrecedenceAnd)
+	p.registerInfix(EqualEqual, p.makeBinaryInfix(PrecedenceEquality), PrecedenceEquality)
+	p.registerInfix(BangEqual, p.makeBinaryInfix(PrecedenceEquality), PrecedenceEquality)
+	p.registerInfix(Greater, p.makeBinaryInfix(PrecedenceComparison), PrecedenceComparison)
+	p.registerInfix(GreaterEqual, p.makeBinaryInfix(PrecedenceComparison), PrecedenceComparison)
+	p.registerInfix(Less, p.makeBinaryInfix(PrecedenceComparison), PrecedenceComparison)
+	p.registerInfix(LessEqual, p.makeBinaryInfix(PrecedenceComparison), PrecedenceComparison)
+	p.registerInfix(Plus, p.makeBinaryInfix(PrecedenceSum), PrecedenceSum)
+	p.registerInfix(Minus, p.makeBinaryInfix(PrecedenceSum), PrecedenceSum)
+	p.registerInfix(Star, p.makeBinaryInfix(PrecedenceProduct), PrecedenceProduct)
+	p.registerInfix(Slash, p.makeBinaryInfix(PrecedenceProduct), PrecedenceProduct)
+	p.registerInfix(Dot, p.parseGet, PrecedenceCall)
+	p.registerInfix(LeftBracket, p.parseIndex, PrecedenceCall)
+	p.registerInfix(LeftParen, p.finishCall, PrecedenceCall)
+}
+
+// parsePrecedence is the Pratt driver: it parses one prefix expression for
+// the current token, then keeps folding in infix operators as long as the
+// next token's precedence outranks precedence - the caller's minimum
+// binding power. Passing an infix operator's own precedence back into the
+// recursive parse for its right-hand side (rather than precedence+1)
+// yields left-associativity, since a subsequent operator at the exact
+// same precedence then fails the loop's strict `<` check one level down
+// and gets picked up by the outer call instead (see makeBinaryInfix);
+// passing precedence-1 instead (see parseAssign, parseTernary) yields
+// right-associativity, since it lets an operator at the same precedence
+// recurse again immediately.
+func (p *Parser) parsePrecedence(precedence Precedence) (expr Expression, err error) {
+	defer un(trace(p, "ParsePrecedence"))
+
+	prefix, ok := p.prefixParseFns[p.peek().Type]
+	if !ok {
+		err = p.error(p.peek(), "Expect expression.")
+		return
+	}
+	p.advance()
+
+	expr, err = prefix()
 	if err != nil {
 		return
 	}
 
-	if p.match(Equal) {
-		equals := p.previous()
-		value, innerErr := p.assignment()
-		if innerErr != nil {
-			err = innerErr
-			return
+	for !p.isAtEnd() && precedence < p.precedenceOf(p.peek().Type) {
+		infix, ok := p.infixParseFns[p.peek().Type]
+		if !ok {
+			break
 		}
+		p.advance()
 
-		if v, ok := expr.(*VariableExpression); ok {
-			expr = &AssignExpression{
-				Name:  v.Name,
-				Value: value,
-			}
-			return
-		} else if v, ok := expr.(*GetExpression); ok {
-			expr = &SetExpression{
-				Object: v.Object,
-				Name:   v.Name,
-				Value:  value,
-			}
+		expr, err = infix(expr)
+		if err != nil {
 			return
 		}
-
-		p.error(equals, "Invalid assignment target.")
-		// don't throw the error, just report it
 	}
 
 	return
 }
 
-func (p *Parser) ternary() (expr Expression, err error) {
-	expr, err = p.or()
+func (p *Parser) parseTrueLiteral() (Expression, error) {
+	return &LiteralExpression{Value: NewBoolLiteral(true)}, nil
+}
+
+func (p *Parser) parseFalseLiteral() (Expression, error) {
+	return &LiteralExpression{Value: NewBoolLiteral(false)}, nil
+}
+
+func (p *Parser) parseNilLiteral() (Expression, error) {
+	return &LiteralExpression{Value: NewNilLiteral()}, nil
+}
+
+func (p *Parser) parseThis() (Expression, error) {
+	return &ThisExpression{Keyword: p.previous()}, nil
+}
+
+func (p *Parser) parseSuper() (expr Expression, err error) {
+	keyword := p.previous()
+
+	_, err = p.consume(Dot, "Expect '.' after 'super'.")
 	if err != nil {
 		return
 	}
 
-	if !p.match(Question) {
+	method, err := p.consume(Identifier, "Expect superclass method name.")
+	if err != nil {
 		return
 	}
 
-	left, err := p.expression()
-	if err != nil {
-		return
+	expr = &SuperExpression{
+		Keyword: keyword,
+		Method:  method,
 	}
+	return
+}
 
-	_, err = p.consume(Colon, "Expect ':' after expression.")
+func (p *Parser) parseNumber() (Expression, error) {
+	return &LiteralExpression{Value: NewNumberLiteral(p.previous().Literal.NumberValue)}, nil
+}
+
+// parseString backs the String, TemplateStart, TemplatePart, and
+// TemplateEnd prefix registrations - the TemplateXxx types are just the
+// literal segments of a "${...}" interpolation (see Scanner.stringLiteral)
+// surrounded by synthetic Plus/LeftParen/RightParen tokens, so from here
+// they're indistinguishable from a plain string.
+func (p *Parser) parseString() (Expression, error) {
+	return &LiteralExpression{Value: NewStringLiteral(p.previous().Literal.StringValue)}, nil
+}
+
+func (p *Parser) parseIdentifier() (Expression, error) {
+	return &VariableExpression{Name: p.previous()}, nil
+}
+
+func (p *Parser) parseGrouping() (expr Expression, err error) {
+	defer un(trace(p, "Grouping"))
+
+	expression, err := p.expression()
 	if err != nil {
 		return
 	}
 
-	right, err := p.ternary()
+	_, err = p.consume(RightParen, "Expect ')' after expression.")
 	if err != nil {
 		return
 	}
 
-	expr = &TernaryExpression{
-		Condition: expr,
-		True:      left,
-		False:     right,
-	}
+	expr = &GroupingExpression{Expression: expression}
 	return
 }
 
-func (p *Parser) or() (expr Expression, err error) {
-	expr, err = p.and()
+func (p *Parser) parseUnary() (expr Expression, err error) {
+	operator := p.previous()
+
+	right, err := p.parsePrecedence(PrecedenceUnary)
 	if err != nil {
 		return
 	}
 
-	for {
-		if !p.match(Or) {
-			break
-		}
+	expr = &UnaryExpression{
+		Operator: operator,
+		Right:    right,
+	}
+	return
+}
 
+// makeBinaryInfix builds the infix parser for a left-associative binary
+// operator that produces a BinaryExpression - every arithmetic and
+// comparison operator.
+func (p *Parser) makeBinaryInfix(precedence Precedence) infixParseFn {
+	return func(left Expression) (expr Expression, err error) {
 		operator := p.previous()
 
-		right, innerErr := p.and()
-		if innerErr != nil {
-			err = innerErr
+		right, err := p.parsePrecedence(precedence)
+		if err != nil {
 			return
 		}
 
-		expr = &LogicalExpression{
-			Left:     expr,
+		expr = &BinaryExpression{
+			Left:     left,
 			Operator: operator,
 			Right:    right,
 		}
-	}
-	return
-}
-
-func (p *Parser) and() (expr Expression, err error) {
-	expr, err = p.equality()
-	if err != nil {
 		return
 	}
+}
 
-	for {
-		if !p.match(And) {
-			break
-		}
-
+// makeLogicalInfix is makeBinaryInfix for `and`/`or`, which build a
+// LogicalExpression instead - the interpreter short-circuits these rather
+// than always evaluating both sides.
+func (p *Parser) makeLogicalInfix(precedence Precedence) infixParseFn {
+	return func(left Expression) (expr Expression, err error) {
 		operator := p.previous()
 
-		right, innerErr := p.equality()
-		if innerErr != nil {
-			err = innerErr
+		right, err := p.parsePrecedence(precedence)
+		if err != nil {
 			return
 		}
 
 		expr = &LogicalExpression{
-			Left:     expr,
+			Left:     left,
 			Operator: operator,
 			Right:    right,
 		}
+		return
 	}
-	return
 }
 
-func (p *Parser) equality() (Expression, error) {
-	return p.binaryExpression(p.comparison, BangEqual, EqualEqual)
-}
+// parseAssign is `=`'s infix parser. It's right-associative (`a = b = c`
+// is `a = (b = c)`), so its right-hand side is parsed one precedence
+// level below its own - see parsePrecedence - and it validates left is a
+// legal assignment target, the same three kinds the old hand-written
+// assignment() checked.
+func (p *Parser) parseAssign(left Expression) (expr Expression, err error) {
+	defer un(trace(p, "Assignment"))
 
-func (p *Parser) comparison() (Expression, error) {
-	return p.binaryExpression(p.term, Greater, GreaterEqual, Less, LessEqual)
-}
+	equals := p.previous()
 
-func (p *Parser) term() (Expression, error) {
-	return p.binaryExpression(p.factor, Minus, Plus)
-}
+	value, err := p.parsePrecedence(PrecedenceAssign - 1)
+	if err != nil {
+		return
+	}
+
+	switch target := left.(type) {
+	case *VariableExpression:
+		expr = &AssignExpression{Name: target.Name, Value: value}
+		return
+	case *GetExpression:
+		expr = &SetExpression{Object: target.Object, Name: target.Name, Value: value}
+		return
+	case *IndexExpression:
+		expr = &IndexSetExpression{Object: target.Object, Bracket: target.Bracket, Index: target.Index, Value: value}
+		return
+	}
 
-func (p *Parser) factor() (expr Expression, err error) {
-	return p.binaryExpression(p.unary, Slash, Star)
+	p.error(equals, "Invalid assignment target.")
+	// don't throw the error, just report it - left parses fine on its own,
+	// we just can't assign to it
+	expr = left
+	return
 }
 
-func (p *Parser) unary() (expr Expression, err error) {
-	if !p.match(Bang, Minus) {
-		return p.call()
+// parseTernary is `?`'s infix parser. The true branch is a full
+// expression (so `cond ? a, b : c` is legal, same as the old hand-written
+// ternary()), and the false branch recurses at one precedence level below
+// PrecedenceTernary so `a ? b : c ? d : e` nests as `a ? b : (c ? d : e)`.
+func (p *Parser) parseTernary(left Expression) (expr Expression, err error) {
+	defer un(trace(p, "Ternary"))
+
+	trueBranch, err := p.expression()
+	if err != nil {
+		return
 	}
 
-	operator := p.previous()
+	_, err = p.consume(Colon, "Expect ':' after expression.")
+	if err != nil {
+		return
+	}
 
-	right, err := p.unary()
+	falseBranch, err := p.parsePrecedence(PrecedenceTernary - 1)
 	if err != nil {
 		return
 	}
 
-	expr = &UnaryExpression{
-		Operator: operator,
-		Right:    right,
+	expr = &TernaryExpression{
+		Condition: left,
+		True:      trueBranch,
+		False:     falseBranch,
 	}
 	return
 }
 
-func (p *Parser) call() (expr Expression, err error) {
-	expr, err = p.primary()
+// parseGet is `.`'s infix parser, producing a GetExpression.
+func (p *Parser) parseGet(left Expression) (expr Expression, err error) {
+	name, err := p.consume(Identifier, "Expect property name after '.'.")
 	if err != nil {
 		return
 	}
 
-	for {
-		if p.match(LeftParen) {
-			expr, err = p.finishCall(expr)
-			if err != nil {
-				return
-			}
-		} else if p.match(Dot) {
-			name, innerErr := p.consume(Identifier, "Expect property name after '.'.")
-			if innerErr != nil {
-				err = innerErr
-				return
-			}
+	expr = &GetExpression{
+		Object: left,
+		Name:   name,
+	}
+	return
+}
 
-			expr = &GetExpression{
-				Object: expr,
-				Name:   name,
-			}
-		} else {
-			break
-		}
+// parseIndex is `[`'s infix parser, producing an IndexExpression.
+func (p *Parser) parseIndex(left Expression) (expr Expression, err error) {
+	bracket := p.previous()
+
+	index, err := p.expression()
+	if err != nil {
+		return
 	}
 
+	_, err = p.consume(RightBracket, "Expect ']' after index.")
+	if err != nil {
+		return
+	}
+
+	expr = &IndexExpression{
+		Object:  left,
+		Bracket: bracket,
+		Index:   index,
+	}
 	return
 }
 
 func (p *Parser) finishCall(callee Expression) (expr Expression, err error) {
+	defer un(trace(p, "Call"))
+
+	if err = p.enterNesting(p.peek()); err != nil {
+		return
+	}
+	defer p.exitNesting()
+
 	arguments := []Expression{}
 
 	if !p.check(RightParen) {
 		for {
-			if len(arguments) >= MaxCallArguments {
+			if len(arguments) >= p.Config.MaxCallArguments {
 				p.error(p.peek(), "Can't have more than 255 arguments.")
 				// don't throw the error, just report it
 			}
 
-			argument, innerErr := p.assignment()
+			argument, innerErr := p.parsePrecedence(PrecedenceLowest)
 			if innerErr != nil {
 				err = innerErr
 				return
@@ -799,6 +1414,10 @@ func (p *Parser) finishCall(callee Expression) (expr Expression, err error) {
 			if !p.match(Comma) {
 				break
 			}
+
+			if p.Config.AllowTrailingCommas && p.check(RightParen) {
+				break
+			}
 		}
 	}
 
@@ -815,96 +1434,93 @@ func (p *Parser) finishCall(callee Expression) (expr Expression, err error) {
 	return
 }
 
-func (p *Parser) primary() (expr Expression, err error) {
-	if p.match(True) {
-		expr = &LiteralExpression{
-			Value: NewBoolLiteral(true),
-		}
-		return
-	}
+func (p *Parser) listExpression() (expr Expression, err error) {
+	defer un(trace(p, "List"))
 
-	if p.match(False) {
-		expr = &LiteralExpression{
-			Value: NewBoolLiteral(false),
-		}
-		return
-	}
+	bracket := p.previous()
 
-	if p.match(Nil) {
-		expr = &LiteralExpression{
-			Value: NewNilLiteral(),
+	elements := []Expression{}
+	if !p.check(RightBracket) {
+		for {
+			element, innerErr := p.parsePrecedence(PrecedenceLowest)
+			if innerErr != nil {
+				err = innerErr
+				return
+			}
+			elements = append(elements, element)
+
+			if !p.match(Comma) {
+				break
+			}
+
+			if p.Config.AllowTrailingCommas && p.check(RightBracket) {
+				break
+			}
 		}
-		return
 	}
 
-	if p.match(This) {
-		expr = &ThisExpression{
-			Keyword: p.previous(),
-		}
+	_, err = p.consume(RightBracket, "Expect ']' after list elements.")
+	if err != nil {
 		return
 	}
 
-	if p.match(Super) {
-		keyword := p.previous()
+	expr = &ListExpression{
+		Bracket:  bracket,
+		Elements: elements,
+	}
+	return
+}
 
-		_, err = p.consume(Dot, "Expect '.' after 'super'.")
-		if err != nil {
-			return
-		}
+func (p *Parser) mapExpression() (expr Expression, err error) {
+	defer un(trace(p, "Map"))
 
-		method, innerErr := p.consume(Identifier, "Expect superclass method name.")
-		if innerErr != nil {
-			err = innerErr
-			return
-		}
+	brace := p.previous()
 
-		expr = &SuperExpression{
-			Keyword: keyword,
-			Method:  method,
-		}
-		return
-	}
+	keys := []Expression{}
+	values := []Expression{}
+	if !p.check(RightBrace) {
+		for {
+			key, innerErr := p.parsePrecedence(PrecedenceLowest)
+			if innerErr != nil {
+				err = innerErr
+				return
+			}
 
-	if p.match(Number) {
-		expr = &LiteralExpression{
-			Value: NewNumberLiteral(p.previous().Literal.NumberValue),
-		}
-		return
-	}
+			_, innerErr = p.consume(Colon, "Expect ':' after map key.")
+			if innerErr != nil {
+				err = innerErr
+				return
+			}
 
-	if p.match(String) {
-		expr = &LiteralExpression{
-			Value: NewStringLiteral(p.previous().Literal.StringValue),
-		}
-		return
-	}
+			value, innerErr := p.parsePrecedence(PrecedenceLowest)
+			if innerErr != nil {
+				err = innerErr
+				return
+			}
 
-	if p.match(Identifier) {
-		expr = &VariableExpression{
-			Name: p.previous(),
-		}
-		return
-	}
+			keys = append(keys, key)
+			values = append(values, value)
 
-	if p.match(LeftParen) {
-		expression, innerErr := p.expression()
-		if innerErr != nil {
-			err = innerErr
-			return
-		}
+			if !p.match(Comma) {
+				break
+			}
 
-		_, err = p.consume(RightParen, "Expect ')' after expression.")
-		if err != nil {
-			return
+			if p.Config.AllowTrailingCommas && p.check(RightBrace) {
+				break
+			}
 		}
+	}
 
-		expr = &GroupingExpression{
-			Expression: expression,
-		}
+	_, err = p.consume(RightBrace, "Expect '}' after map entries.")
+	if err != nil {
 		return
 	}
 
-	err = p.error(p.peek(), "Expect expression.")
+	expr = &MapExpression{
+		Brace:  brace,
+		Keys:   keys,
+		Values: values,
+	}
 	return
 }
 
@@ -919,6 +1535,17 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().Type == tokenType
 }
 
+// checkNext is check, one token further ahead - used where a production
+// needs to distinguish itself from another starting with the same token,
+// like a loop label (`Identifier ':'`) versus a bare expression statement
+// that happens to start with an identifier.
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isAtEnd() || int(p.Current)+1 >= len(p.Tokens) {
+		return false
+	}
+	return p.Tokens[p.Current+1].Type == tokenType
+}
+
 func (p *Parser) match(tokenTypes ...TokenType) bool {
 	for _, tokenType := range tokenTypes {
 		if p.check(tokenType) {
@@ -971,12 +1598,26 @@ func (p *Parser) isAtEnd() bool {
 }
 
 func (p *Parser) error(token *Token, message string) error {
-	reportError(token, message)
+	p.Diagnostics.Add(PhaseParse, token, message)
 
-	return &ParserError{
+	parserErr := &ParserError{
 		Message: message,
 		Tokens:  []*Token{token},
 	}
+	p.ErrorList.Add(parserErr)
+
+	if p.Current == p.syncPos {
+		p.syncCount++
+	} else {
+		p.syncPos = p.Current
+		p.syncCount = 1
+	}
+
+	if p.syncCount > maxSyncAttempts {
+		panic(bailout{})
+	}
+
+	return parserErr
 }
 
 func (p *Parser) synchronize() {
@@ -997,8 +1638,12 @@ func (p *Parser) synchronize() {
 			fallthrough
 		case For:
 			fallthrough
+		case Foreach:
+			fallthrough
 		case Fun:
 			fallthrough
+		case Import:
+			fallthrough
 		case If:
 			fallthrough
 		case Print:
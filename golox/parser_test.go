@@ -6,51 +6,33 @@ import (
 
 func TestBinaryExpression(t *testing.T) {
 	// -123 * (45.67)
+	source := "-123 * (45.67);"
 	expectedResult := "(* (- 123) (group 45.67))"
 
-	tokens := []*Token{
-		{
-			Type:   Minus,
-			Lexeme: "-",
-			Line:   1,
-		},
-		{
-			Type:    Number,
-			Lexeme:  "123",
-			Literal: NewNumberLiteral(123),
-			Line:    1,
-		},
-		{
-			Type:   Star,
-			Lexeme: "*",
-			Line:   1,
-		},
-		{
-			Type:   LeftParen,
-			Lexeme: "(",
-			Line:   1,
-		},
-		{
-			Type:    Number,
-			Lexeme:  "45.67",
-			Literal: NewNumberLiteral(45.67),
-			Line:    1,
-		},
-		{
-			Type:   RightParen,
-			Lexeme: ")",
-			Line:   1,
-		},
-		{
-			Type: EOF,
-			Line: 1,
-		},
+	diagnostics := NewDiagnostics()
+
+	scanner := NewScanner(source, nil, diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, diagnostics)
+	statements, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+	if diagnostics.HasErrors() {
+		t.Fatalf("Parse failed: %s", diagnostics.Format(source))
 	}
 
-	parser := NewParser(tokens)
-	expression := parser.Parse()
+	if len(statements) != 1 {
+		t.Fatalf("Expected 1 statement, got %d", len(statements))
+	}
+
+	expressionStatement, ok := statements[0].(*ExpressionStatement)
+	if !ok {
+		t.Fatalf("Expected an ExpressionStatement, got %T", statements[0])
+	}
 
-	result, err := (&ExpressionPrinter{}).Print(expression)
+	result, err := (&ExpressionPrinter{}).Print(expressionStatement.Expression)
 	if err != nil {
 		t.Fatalf("Print failed: %s", err)
 	}
@@ -0,0 +1,713 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	peg "github.com/yhirose/go-peg"
+)
+
+// PegParser is an alternative front end selected with --parser=peg. It
+// parses Lox source with the PEG grammar in lox.peg (loaded at startup, so
+// the grammar can be tweaked without recompiling golox) instead of the
+// hand-written recursive-descent parser in parser.go, but builds the exact
+// same Expression/Statement trees - see peg_parser_test.go for the
+// differential test that holds the two front ends to that contract.
+type PegParser struct {
+	grammar *peg.Parser
+
+	// reset per Parse call; actions close over these rather than the
+	// source/line bookkeeping Parser keeps on itself, since the grammar's
+	// rule actions are bound once and reused across parses
+	source      string
+	lineStarts  []int
+	Diagnostics *Diagnostics
+}
+
+// NewPegParser loads and compiles the PEG grammar at grammarPath and binds
+// its semantic actions.
+func NewPegParser(grammarPath string) (*PegParser, error) {
+	source, err := ioutil.ReadFile(grammarPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading PEG grammar: %w", err)
+	}
+
+	grammar, err := peg.NewParser(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("compiling PEG grammar %s: %w", grammarPath, err)
+	}
+
+	p := &PegParser{grammar: grammar}
+	p.bindActions()
+
+	return p, nil
+}
+
+// Parse runs source through the PEG grammar and returns the top-level
+// program statements, or nil with a Diagnostic appended to diagnostics on
+// a grammar mismatch - the same error-reporting contract Parser.Parse
+// follows.
+func (p *PegParser) Parse(source string, diagnostics *Diagnostics) (statements []Statement) {
+	p.source = source
+	p.lineStarts = lineStarts(source)
+	p.Diagnostics = diagnostics
+
+	value, err := p.grammar.ParseAndGetValue(source, nil)
+	if err != nil {
+		p.Diagnostics.AddLine(PhaseParse, nil, p.lineAt(0), p.columnAt(0), err.Error())
+		return nil
+	}
+
+	statements, _ = value.([]Statement)
+	return
+}
+
+// lineAt converts a byte offset into source into a 1-based line number,
+// the same numbering Scanner/Token use.
+func (p *PegParser) lineAt(pos int) uint {
+	line := 1
+	for _, start := range p.lineStarts {
+		if pos < start {
+			break
+		}
+		line++
+	}
+	return uint(line)
+}
+
+// columnAt converts a byte offset into source into a 1-based column on
+// whatever line it falls on, the same numbering Scanner.column produces.
+func (p *PegParser) columnAt(pos int) uint {
+	lineStart := 0
+	for _, start := range p.lineStarts {
+		if pos < start {
+			break
+		}
+		lineStart = start
+	}
+	return uint(pos-lineStart) + 1
+}
+
+func lineStarts(source string) []int {
+	var starts []int
+	for idx, ch := range source {
+		if ch == '\n' {
+			starts = append(starts, idx+1)
+		}
+	}
+	return starts
+}
+
+// token synthesizes the *Token the recursive-descent parser would have
+// attached to the same lexeme, so downstream passes (the resolver, the
+// interpreter's error reporting) can't tell which front end produced the
+// tree. v.Pos is where the rule itself started matching, which for a
+// rule like Identifier that trails off into %whitespace isn't where the
+// lexeme text begins - v.Ts[0].Pos (set by the grammar's '<' '>' token
+// boundary) is, so prefer that when it's present.
+func (p *PegParser) token(tokenType TokenType, v *peg.Values) *Token {
+	lexeme := v.Token()
+
+	pos := v.Pos
+	if len(v.Ts) > 0 {
+		pos = v.Ts[0].Pos
+	}
+
+	return &Token{
+		Type:   tokenType,
+		Lexeme: lexeme,
+		Line:   p.lineAt(pos),
+		Column: p.columnAt(pos),
+		Offset: uint(pos),
+		Length: uint(len(lexeme)),
+	}
+}
+
+// keywordToken synthesizes the *Token for a punctuation/keyword lexeme the
+// grammar matched but didn't capture into v.Vs (e.g. the 'foreach' in
+// ForeachStmt, the '[' opening a ListExpression) - same contract as token,
+// just with the lexeme and position supplied directly instead of read off
+// v.Token()/v.Ts.
+func (p *PegParser) keywordToken(tokenType TokenType, lexeme string, pos int) *Token {
+	return &Token{
+		Type:   tokenType,
+		Lexeme: lexeme,
+		Line:   p.lineAt(pos),
+		Column: p.columnAt(pos),
+		Offset: uint(pos),
+		Length: uint(len(lexeme)),
+	}
+}
+
+// attachLabel threads a LabeledStmt's label onto the WhileStatement inside
+// statement - itself for a labeled while loop, or nested as the last
+// element of a BlockStatement for a labeled for loop with an initializer -
+// mirroring how Parser.forStatement/whileStatement thread label straight
+// into the WhileStatement they build.
+func attachLabel(statement Statement, label *Token) {
+	switch s := statement.(type) {
+	case *WhileStatement:
+		s.Label = label
+	case *BlockStatement:
+		if len(s.Statements) > 0 {
+			attachLabel(s.Statements[len(s.Statements)-1], label)
+		}
+	}
+}
+
+func (p *PegParser) bindActions() {
+	g := p.grammar.Grammar
+
+	g["Program"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		statements := make([]Statement, len(v.Vs))
+		for idx, child := range v.Vs {
+			statements[idx] = child.(Statement)
+		}
+		return statements, nil
+	}
+
+	g["Declaration"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		return v.Vs[0], nil
+	}
+
+	g["VarDecl"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		name := v.Vs[0].(*Token)
+
+		var typeAnnotation *Token
+		var initializer Expression
+		for _, child := range v.Vs[1:] {
+			switch c := child.(type) {
+			case *Token:
+				typeAnnotation = c
+			case Expression:
+				initializer = c
+			}
+		}
+
+		return &VarStatement{
+			Name:        name,
+			Initializer: initializer,
+			Type:        typeAnnotation,
+		}, nil
+	}
+
+	g["ClassDecl"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		name := v.Vs[0].(*Token)
+
+		var superclass *VariableExpression
+		methods := []*FunctionStatement{}
+		for _, child := range v.Vs[1:] {
+			switch c := child.(type) {
+			case *Token:
+				superclass = &VariableExpression{Name: c}
+			case *FunctionStatement:
+				methods = append(methods, c)
+			}
+		}
+
+		return &ClassStatement{
+			Name:       name,
+			Superclass: superclass,
+			Methods:    methods,
+		}, nil
+	}
+
+	g["Method"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		name := v.Vs[0].(*Token)
+		fn := v.Vs[1].(*FunctionStatement)
+		fn.Name = name
+		return fn, nil
+	}
+
+	g["FunDecl"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		name := v.Vs[0].(*Token)
+		fn := v.Vs[1].(*FunctionStatement)
+		fn.Name = name
+		return fn, nil
+	}
+
+	g["FunBody"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		// Parser.function always starts params/paramTypes as empty slices,
+		// never nil, even with zero parameters - match that here so the
+		// differential test's JSON comparison doesn't see a spurious
+		// []*Token(nil) vs []*Token{} mismatch.
+		fn := &FunctionStatement{Params: []*Token{}, ParamTypes: []*Token{}}
+
+		for _, child := range v.Vs {
+			switch c := child.(type) {
+			case []*paramCapture:
+				for _, param := range c {
+					fn.Params = append(fn.Params, param.name)
+					fn.ParamTypes = append(fn.ParamTypes, param.typeAnnotation)
+				}
+			case *Token:
+				fn.ReturnType = c
+			case []Statement:
+				fn.Body = c
+			}
+		}
+
+		return fn, nil
+	}
+
+	g["Params"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		params := make([]*paramCapture, len(v.Vs))
+		for idx, child := range v.Vs {
+			params[idx] = child.(*paramCapture)
+		}
+		return params, nil
+	}
+
+	g["Param"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		capture := &paramCapture{name: v.Vs[0].(*Token)}
+		if len(v.Vs) > 1 {
+			capture.typeAnnotation = v.Vs[1].(*Token)
+		}
+		return capture, nil
+	}
+
+	g["ImportDecl"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		path := v.Vs[0].(*Token)
+
+		var alias *Token
+		if len(v.Vs) > 1 {
+			alias = v.Vs[1].(*Token)
+		}
+
+		return &ImportStatement{
+			Keyword: p.keywordToken(Import, "import", v.Pos),
+			Path:    path,
+			Alias:   alias,
+		}, nil
+	}
+
+	g["Stmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		// Block's own action returns a bare []Statement (FunBody wants it
+		// unwrapped for FunctionStatement.Body), so wrap it here - Stmt is
+		// the only place a Block can stand in as a Statement in its own
+		// right, e.g. as a loop/if body.
+		if statements, ok := v.Vs[0].([]Statement); ok {
+			return &BlockStatement{Statements: statements}, nil
+		}
+		return v.Vs[0], nil
+	}
+
+	g["LabeledStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		label := v.Vs[0].(*Token)
+		statement := v.Vs[1].(Statement)
+		attachLabel(statement, label)
+		return statement, nil
+	}
+
+	g["ForStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		var initializer Statement
+		var condition, increment Expression
+
+		rest := v.Vs
+		if init, ok := rest[0].(Statement); ok {
+			initializer = init
+		}
+		rest = rest[1:]
+
+		for len(rest) > 0 {
+			if _, ok := rest[0].(Statement); ok {
+				break
+			}
+			if expr, ok := rest[0].(Expression); ok {
+				if condition == nil {
+					condition = expr
+				} else {
+					increment = expr
+				}
+				rest = rest[1:]
+				continue
+			}
+			break
+		}
+
+		body := rest[len(rest)-1].(Statement)
+
+		var result Statement = body
+		if increment != nil {
+			result = &BlockStatement{
+				Statements: []Statement{
+					body,
+					&ExpressionStatement{Expression: increment},
+				},
+			}
+		}
+
+		if condition == nil {
+			condition = &LiteralExpression{Value: NewBoolLiteral(true)}
+		}
+
+		result = &WhileStatement{
+			Condition: condition,
+			Body:      result,
+		}
+
+		if initializer != nil {
+			result = &BlockStatement{
+				Statements: []Statement{initializer, result},
+			}
+		}
+
+		return result, nil
+	}
+
+	g["ForInit"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		if len(v.Vs) == 0 {
+			// the bare ';' case: no initializer statement at all
+			return nil, nil
+		}
+		return v.Vs[0], nil
+	}
+
+	g["ForeachStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		return &ForeachStatement{
+			Keyword:  p.keywordToken(Foreach, "foreach", v.Pos),
+			Name:     v.Vs[0].(*Token),
+			Iterable: v.Vs[1].(Expression),
+			Body:     v.Vs[2].(Statement),
+		}, nil
+	}
+
+	g["IfStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		statement := &IfStatement{
+			Condition: v.Vs[0].(Expression),
+			Then:      v.Vs[1].(Statement),
+		}
+		if len(v.Vs) > 2 {
+			statement.Else = v.Vs[2].(Statement)
+		}
+		return statement, nil
+	}
+
+	g["PrintStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		return &PrintStatement{Expression: v.Vs[0].(Expression)}, nil
+	}
+
+	g["ReturnStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		statement := &ReturnStatement{Keyword: p.keywordToken(Return, "return", v.Pos)}
+		if len(v.Vs) > 0 {
+			statement.Value = v.Vs[0].(Expression)
+		}
+		return statement, nil
+	}
+
+	g["WhileStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		return &WhileStatement{
+			Condition: v.Vs[0].(Expression),
+			Body:      v.Vs[1].(Statement),
+		}, nil
+	}
+
+	g["BreakStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		statement := &BreakStatement{Keyword: p.keywordToken(Break, "break", v.Pos)}
+		if len(v.Vs) > 0 {
+			statement.Label = v.Vs[0].(*Token)
+		}
+		return statement, nil
+	}
+
+	g["ContinueStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		statement := &ContinueStatement{Keyword: p.keywordToken(Continue, "continue", v.Pos)}
+		if len(v.Vs) > 0 {
+			statement.Label = v.Vs[0].(*Token)
+		}
+		return statement, nil
+	}
+
+	g["Block"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		statements := make([]Statement, len(v.Vs))
+		for idx, child := range v.Vs {
+			statements[idx] = child.(Statement)
+		}
+		return statements, nil
+	}
+
+	g["ExprStmt"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		return &ExpressionStatement{Expression: v.Vs[0].(Expression)}, nil
+	}
+
+	g["Expression"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		expr := v.Vs[0].(Expression)
+		if len(v.Vs) == 1 {
+			return expr, nil
+		}
+
+		return &BinaryExpression{
+			Left:     expr,
+			Operator: p.keywordToken(Comma, ",", v.Pos),
+			Right:    v.Vs[1].(Expression),
+		}, nil
+	}
+
+	g["Assignment"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		expr := v.Vs[0].(Expression)
+		if len(v.Vs) == 1 {
+			return expr, nil
+		}
+
+		value := v.Vs[1].(Expression)
+		equals := p.keywordToken(Equal, "=", v.Pos)
+
+		switch target := expr.(type) {
+		case *VariableExpression:
+			return &AssignExpression{Name: target.Name, Value: value}, nil
+		case *GetExpression:
+			return &SetExpression{Object: target.Object, Name: target.Name, Value: value}, nil
+		case *IndexExpression:
+			return &IndexSetExpression{Object: target.Object, Bracket: target.Bracket, Index: target.Index, Value: value}, nil
+		}
+
+		p.Diagnostics.Add(PhaseParse, equals, "Invalid assignment target.")
+		return expr, nil
+	}
+
+	g["Ternary"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		expr := v.Vs[0].(Expression)
+		if len(v.Vs) == 1 {
+			return expr, nil
+		}
+
+		return &TernaryExpression{
+			Condition: expr,
+			True:      v.Vs[1].(Expression),
+			False:     v.Vs[2].(Expression),
+		}, nil
+	}
+
+	// LogicOrOp/LogicAndOp/EqualityOp/ComparisonOp/TermOp/FactorOp/UnaryOp
+	// exist purely to give their bare literal alternation a value at all -
+	// a bare ('+' / '-') never contributes one on its own, only a named
+	// rule reference does - so each returns the full *Token for its
+	// matched operator (lexeme plus position), with its real TokenType
+	// filled in from the operators map rather than by the rule itself.
+	bindOperatorToken := func(name string, operators map[string]TokenType) {
+		g[name].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+			token := p.token(EOF, v)
+			token.Type = operators[token.Lexeme]
+			return token, nil
+		}
+	}
+	bindOperatorToken("LogicOrOp", map[string]TokenType{"or": Or})
+	bindOperatorToken("LogicAndOp", map[string]TokenType{"and": And})
+	bindOperatorToken("EqualityOp", map[string]TokenType{"!=": BangEqual, "==": EqualEqual})
+	bindOperatorToken("ComparisonOp", map[string]TokenType{">=": GreaterEqual, "<=": LessEqual, ">": Greater, "<": Less})
+	bindOperatorToken("TermOp", map[string]TokenType{"+": Plus, "-": Minus})
+	bindOperatorToken("FactorOp", map[string]TokenType{"*": Star, "/": Slash})
+	bindOperatorToken("UnaryOp", map[string]TokenType{"!": Bang, "-": Minus})
+
+	bindLogical := func(name string) {
+		g[name].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+			expr := v.Vs[0].(Expression)
+			rest := v.Vs[1:]
+			for len(rest) >= 2 {
+				expr = &LogicalExpression{
+					Left:     expr,
+					Operator: rest[0].(*Token),
+					Right:    rest[1].(Expression),
+				}
+				rest = rest[2:]
+			}
+			return expr, nil
+		}
+	}
+	bindLogical("LogicOr")
+	bindLogical("LogicAnd")
+
+	// Equality/Comparison/Term/Factor all reduce a left operand plus a list
+	// of (operator token, right operand) pairs left-associatively, the same
+	// tree shape Parser.makeBinaryInfix builds.
+	bindBinary := func(name string) {
+		g[name].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+			expr := v.Vs[0].(Expression)
+			rest := v.Vs[1:]
+			for len(rest) >= 2 {
+				expr = &BinaryExpression{
+					Left:     expr,
+					Operator: rest[0].(*Token),
+					Right:    rest[1].(Expression),
+				}
+				rest = rest[2:]
+			}
+			return expr, nil
+		}
+	}
+	bindBinary("Equality")
+	bindBinary("Comparison")
+	bindBinary("Term")
+	bindBinary("Factor")
+
+	g["Unary"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		if len(v.Vs) == 1 {
+			return v.Vs[0], nil
+		}
+
+		return &UnaryExpression{
+			Operator: v.Vs[0].(*Token),
+			Right:    v.Vs[1].(Expression),
+		}, nil
+	}
+
+	g["Call"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		expr := v.Vs[0].(Expression)
+		for _, child := range v.Vs[1:] {
+			switch c := child.(type) {
+			case *argsCapture:
+				expr = &CallExpression{
+					Callee:    expr,
+					Paren:     c.paren,
+					Arguments: c.arguments,
+				}
+			case *Token:
+				expr = &GetExpression{Object: expr, Name: c}
+			case *indexCapture:
+				expr = &IndexExpression{
+					Object:  expr,
+					Bracket: c.bracket,
+					Index:   c.index,
+				}
+			}
+		}
+		return expr, nil
+	}
+
+	g["Args"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		arguments := make([]Expression, len(v.Vs))
+		for idx, child := range v.Vs {
+			arguments[idx] = child.(Expression)
+		}
+		return &argsCapture{
+			arguments: arguments,
+			paren:     p.keywordToken(RightParen, ")", v.Ts[0].Pos),
+		}, nil
+	}
+
+	g["IndexArgs"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		return &indexCapture{
+			index:   v.Vs[0].(Expression),
+			bracket: p.keywordToken(LeftBracket, "[", v.Ts[0].Pos),
+		}, nil
+	}
+
+	g["Primary"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		// the bare keyword/'(' literals below aren't wrapped in a '<' '>'
+		// token boundary the way Identifier/Number are, so v.Token() still
+		// carries whatever trailing %whitespace the literal auto-skipped.
+		text := strings.TrimSpace(v.Token())
+
+		switch text {
+		case "true":
+			return &LiteralExpression{Value: NewBoolLiteral(true)}, nil
+		case "false":
+			return &LiteralExpression{Value: NewBoolLiteral(false)}, nil
+		case "nil":
+			return &LiteralExpression{Value: NewNilLiteral()}, nil
+		case "this":
+			return &ThisExpression{Keyword: p.keywordToken(This, "this", v.Pos)}, nil
+		}
+
+		if strings.HasPrefix(text, "super") {
+			return &SuperExpression{
+				Keyword: p.keywordToken(Super, "super", v.Pos),
+				Method:  v.Vs[0].(*Token),
+			}, nil
+		}
+
+		// everything else captured exactly one child: Number/String/List/Map
+		// already produced an Expression, a bare Identifier only produced
+		// the *Token (it's also used standalone in VarDecl/Params/etc), and
+		// the parenthesized case needs the GroupingExpression wrapper.
+		switch child := v.Vs[0].(type) {
+		case *Token:
+			return &VariableExpression{Name: child}, nil
+		case Expression:
+			if len(v.Vs) == 1 && strings.HasPrefix(text, "(") {
+				return &GroupingExpression{Expression: child}, nil
+			}
+			return child, nil
+		default:
+			return child, nil
+		}
+	}
+
+	g["List"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		elements := make([]Expression, len(v.Vs))
+		for idx, child := range v.Vs {
+			elements[idx] = child.(Expression)
+		}
+		return &ListExpression{
+			Bracket:  p.keywordToken(LeftBracket, "[", v.Pos),
+			Elements: elements,
+		}, nil
+	}
+
+	g["Map"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		keys := []Expression{}
+		values := []Expression{}
+		for idx, child := range v.Vs {
+			if idx%2 == 0 {
+				keys = append(keys, child.(Expression))
+			} else {
+				values = append(values, child.(Expression))
+			}
+		}
+		return &MapExpression{
+			Brace:  p.keywordToken(LeftBrace, "{", v.Pos),
+			Keys:   keys,
+			Values: values,
+		}, nil
+	}
+
+	g["Identifier"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		return p.token(Identifier, v), nil
+	}
+
+	g["Number"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		number, err := strconv.ParseFloat(v.Token(), 64)
+		if err != nil {
+			return nil, err
+		}
+		return &LiteralExpression{Value: NewNumberLiteral(number)}, nil
+	}
+
+	g["String"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		token := v.Vs[0].(*Token)
+		return &LiteralExpression{Value: NewStringLiteral(token.Literal.StringValue)}, nil
+	}
+
+	g["StringToken"].Action = func(v *peg.Values, d peg.Any) (peg.Any, error) {
+		token := p.token(String, v)
+		unquoted := token.Lexeme[1 : len(token.Lexeme)-1]
+		token.Literal = NewStringLiteral(unquoted)
+		return token, nil
+	}
+}
+
+// paramCapture threads a parameter's name and optional type annotation
+// through the Params/FunBody actions, mirroring the parallel
+// Params/ParamTypes slices FunctionStatement stores them in.
+type paramCapture struct {
+	name           *Token
+	typeAnnotation *Token
+}
+
+// argsCapture threads a call's arguments and its closing ')' token (the
+// position CallExpression.Paren reports, same as Parser.finishCall) out of
+// Args and into Call's action.
+type argsCapture struct {
+	arguments []Expression
+	paren     *Token
+}
+
+// indexCapture threads an index expression's subscript and its opening '['
+// token (the position IndexExpression.Bracket reports) out of IndexArgs and
+// into Call's action, same reasoning as argsCapture.
+type indexCapture struct {
+	index   Expression
+	bracket *Token
+}
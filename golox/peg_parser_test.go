@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// pegCorpus covers the same surface the recursive-descent grammar does -
+// declarations, classes/inheritance, control flow, ternary, break/continue,
+// list/map literals, and imports - so the differential test below is a
+// meaningful equivalence oracle, not just a smoke test.
+var pegCorpus = []string{
+	`var x = 1;`,
+	`var x: number = 1 + 2 * 3;`,
+	`print 1 < 2 and 2 <= 3 or !false;`,
+	`print true ? "yes" : "no";`,
+	`for (var i = 0; i < 10; i = i + 1) { print i; }`,
+	`foreach (item in [1, 2, 3]) { print item; }`,
+	`while (true) { break; continue; }`,
+	`fun add(a: number, b: number): number { return a + b; }`,
+	`class Animal { speak() { print "..."; } }`,
+	`class Dog < Animal { speak() { print super.speak(); } }`,
+	`var m = {"a": 1, "b": 2}; print m["a"];`,
+	`import "math" as m; print m.sqrt(4);`,
+	`var obj = Dog(); obj.name = "Rex"; print obj.name;`,
+}
+
+func TestPegParserMatchesRecursiveDescent(t *testing.T) {
+	pegParser, err := NewPegParser("lox.peg")
+	if err != nil {
+		t.Fatalf("NewPegParser failed: %s", err)
+	}
+
+	for _, source := range pegCorpus {
+		recursiveDiagnostics := NewDiagnostics()
+		scanner := NewScanner(source, nil, recursiveDiagnostics)
+		scanner.ScanTokens()
+
+		recursive := NewParser(scanner.Tokens, false, recursiveDiagnostics)
+		recursiveStatements, _ := recursive.Parse()
+		if recursiveDiagnostics.HasErrors() {
+			t.Fatalf("recursive-descent parse failed for %q", source)
+		}
+
+		pegDiagnostics := NewDiagnostics()
+		pegStatements := pegParser.Parse(source, pegDiagnostics)
+		if pegDiagnostics.HasErrors() {
+			t.Fatalf("PEG parse failed for %q", source)
+		}
+
+		recursiveJSON, err := MarshalStatements(recursiveStatements)
+		if err != nil {
+			t.Fatalf("MarshalStatements(recursive) failed for %q: %s", source, err)
+		}
+
+		pegJSON, err := MarshalStatements(pegStatements)
+		if err != nil {
+			t.Fatalf("MarshalStatements(peg) failed for %q: %s", source, err)
+		}
+
+		if !bytes.Equal(recursiveJSON, pegJSON) {
+			t.Fatalf("AST mismatch for %q:\nrecursive: %s\npeg:       %s", source, recursiveJSON, pegJSON)
+		}
+	}
+}
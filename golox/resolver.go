@@ -32,17 +32,47 @@ func NewResolver(interpreter *Interpreter) Resolver {
 	}
 }
 
-func (r *Resolver) Resolve(statements []Statement) {
+// Resolve walks statements to bind every local variable and method lookup
+// to a lexical depth (see resolveLocal), reporting any problem it finds
+// along the way through r.Interpreter.Diagnostics. It returns statements
+// reordered by resolveStatements, which callers must use in place of the
+// slice they passed in - the Interpreter relies on that order to see a
+// forward-referenced top-level declaration before the statement that
+// depends on it runs.
+func (r *Resolver) Resolve(statements []Statement) []Statement {
 	if r.Debug {
 		fmt.Println("Running resolver ...")
 	}
 
-	err := r.resolveStatements(statements)
-	if err != nil {
-		// TODO: runtime error is not right here
-		runtimeError(err)
-		return
+	statements = r.resolveStatements(statements)
+
+	// Visit below reports through r.Interpreter.Diagnostics instead of
+	// stopping at the first problem, so one Resolve call can surface every
+	// undefined variable, illegal return, and duplicate declaration in the
+	// program instead of just the first - Walk's error return is always
+	// nil as a result and isn't worth plumbing back out of Resolve.
+	Walk(r, statements)
+
+	return statements
+}
+
+// resolveStatements topologically orders statements' top-level
+// declarations (toposort.go) so a forward reference - `class A < B {}`
+// before B is declared, `var x = f();` before f is declared - resolves
+// against a declaration that already exists, the same as if the source
+// had been written in dependency order by hand. It only reorders the
+// top-level list Resolve was called with; a nested statement list (a
+// block, a function body, ...) is still walked in source order, so a name
+// used there before its local declaration doesn't resolve to that local
+// (resolveLocal falls back to an enclosing scope or a global).
+func (r *Resolver) resolveStatements(statements []Statement) []Statement {
+	groups := toposort(statements, r.Interpreter.Diagnostics)
+
+	ordered := make([]Statement, 0, len(statements))
+	for _, group := range groups {
+		ordered = append(ordered, group...)
 	}
+	return ordered
 }
 
 func (r *Resolver) beginScope() {
@@ -64,7 +94,7 @@ func (r *Resolver) declare(name *Token) {
 
 	scope, _ := r.Scopes.Peek()
 	if _, ok := scope[name.Lexeme]; ok {
-		reportError(name, "Already a variable with this name in this scope.")
+		r.Interpreter.Diagnostics.Add(PhaseResolve, name, "Already a variable with this name in this scope.")
 	}
 
 	scope[name.Lexeme] = false
@@ -93,154 +123,7 @@ func (r *Resolver) resolveLocal(expression Expression, name *Token) {
 	// assume global if we didn't find it
 }
 
-func (r *Resolver) VisitExpressionStatement(statement *ExpressionStatement) (value *Value, err error) {
-	err = r.resolveExpression(statement.Expression)
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-func (r *Resolver) VisitFunctionStatement(statement *FunctionStatement) (value *Value, err error) {
-	r.declare(statement.Name)
-	r.define(statement.Name)
-
-	err = r.resolveFunction(statement, FunctionTypeFunction)
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-func (r *Resolver) VisitPrintStatement(statement *PrintStatement) (value *Value, err error) {
-	err = r.resolveExpression(statement.Expression)
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-func (r *Resolver) VisitReturnStatement(statement *ReturnStatement) (value *Value, err error) {
-	if r.CurrentFunction == FunctionTypeNone {
-		reportError(statement.Keyword, "Can't return from top-level code.")
-	}
-
-	if statement.Value != nil {
-		err = r.resolveExpression(statement.Value)
-		if err != nil {
-			return
-		}
-	}
-
-	return
-}
-
-func (r *Resolver) VisitBlockStatement(statement *BlockStatement) (value *Value, err error) {
-	r.beginScope()
-	err = r.resolveStatements(statement.Statements)
-	if err != nil {
-		return
-	}
-	r.endScope()
-
-	return
-}
-
-func (r *Resolver) VisitIfStatement(statement *IfStatement) (value *Value, err error) {
-	err = r.resolveExpression(statement.Condition)
-	if err != nil {
-		return
-	}
-
-	err = r.resolveStatement(statement.Then)
-	if err != nil {
-		return
-	}
-
-	if statement.Else != nil {
-		err = r.resolveStatement(statement.Else)
-		if err != nil {
-			return
-		}
-	}
-
-	return
-}
-
-func (r *Resolver) VisitVarStatement(statement *VarStatement) (value *Value, err error) {
-	r.declare(statement.Name)
-	if statement.Initializer != nil {
-		err = r.resolveExpression(statement.Initializer)
-		if err != nil {
-			return
-		}
-	}
-	r.define(statement.Name)
-
-	return
-}
-
-func (r *Resolver) VisitWhileStatement(statement *WhileStatement) (value *Value, err error) {
-	err = r.resolveExpression(statement.Condition)
-	if err != nil {
-		return
-	}
-
-	err = r.resolveStatement(statement.Body)
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-func (r *Resolver) VisitBreakStatement(statement *BreakStatement) (value *Value, err error) {
-	return
-}
-
-func (r *Resolver) VisitContinueStatement(statement *ContinueStatement) (value *Value, err error) {
-	return
-}
-
-func (r *Resolver) VisitClassStatement(statement *ClassStatement) (value *Value, err error) {
-	r.declare(statement.Name)
-	r.define(statement.Name)
-
-	r.beginScope()
-
-	// inject "this"
-	scope, _ := r.Scopes.Peek()
-	scope["this"] = true
-
-	for _, method := range statement.Methods {
-		declaration := FunctionTypeMethod
-		r.resolveFunction(method, declaration)
-	}
-
-	r.endScope()
-
-	return
-}
-
-func (r *Resolver) resolveStatements(statements []Statement) error {
-	for _, statement := range statements {
-		err := r.resolveStatement(statement)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func (r *Resolver) resolveStatement(statement Statement) error {
-	_, err := statement.Accept(r)
-	return err
-}
-
-func (r *Resolver) resolveFunction(function *FunctionStatement, functionType FunctionType) (err error) {
+func (r *Resolver) resolveFunction(function *FunctionStatement, functionType FunctionType) error {
 	enclosingFunction := r.CurrentFunction
 
 	r.CurrentFunction = functionType
@@ -251,156 +134,141 @@ func (r *Resolver) resolveFunction(function *FunctionStatement, functionType Fun
 		r.define(param)
 	}
 
-	err = r.resolveStatements(function.Body)
-	if err != nil {
-		return err
+	for _, statement := range function.Body {
+		if err := Walk(r, statement); err != nil {
+			return err
+		}
 	}
 
 	r.endScope()
 	r.CurrentFunction = enclosingFunction
 
-	return
-}
-
-func (r *Resolver) VisitAssignExpression(expression *AssignExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Value)
-	if err != nil {
-		return
-	}
-
-	r.resolveLocal(expression, expression.Name)
-	return
-}
-
-func (r *Resolver) VisitBinaryExpression(expression *BinaryExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Left)
-	if err != nil {
-		return
-	}
-
-	err = r.resolveExpression(expression.Right)
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-func (r *Resolver) VisitTernaryExpression(expression *TernaryExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Condition)
-	if err != nil {
-		return
-	}
-
-	err = r.resolveExpression(expression.True)
-	if err != nil {
-		return
-	}
-
-	err = r.resolveExpression(expression.False)
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-func (r *Resolver) VisitLogicalExpression(expression *LogicalExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Left)
-	if err != nil {
-		return
-	}
-
-	err = r.resolveExpression(expression.Right)
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-func (r *Resolver) VisitUnaryExpression(expression *UnaryExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Right)
-	if err != nil {
-		return
-	}
-
-	return
+	return nil
 }
 
-func (r *Resolver) VisitCallExpression(expression *CallExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Callee)
-	if err != nil {
-		return
-	}
-
-	for _, argument := range expression.Arguments {
-		err = r.resolveExpression(argument)
-		if err != nil {
-			return
+// Visit implements Visitor (ast_walk.go). Most node types need nothing
+// beyond the scope-tracking a plain recursive descent already gives them,
+// so they return (r, nil) and let Walk's generic descent handle their
+// children in the same order the old per-type VisitXxx methods did. Node
+// types that open or close a scope - Block, Function, Class, Foreach,
+// Var's declare/define split - do their own descent (in the right order
+// relative to beginScope/endScope) and return (nil, nil) so Walk doesn't
+// also descend generically.
+func (r *Resolver) Visit(node Node) (Visitor, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+
+	case *BlockStatement:
+		r.beginScope()
+		for _, statement := range n.Statements {
+			if err := Walk(r, statement); err != nil {
+				return nil, err
+			}
 		}
-	}
-
-	return
-}
+		r.endScope()
+		return nil, nil
+
+	case *FunctionStatement:
+		r.declare(n.Name)
+		r.define(n.Name)
+		if err := r.resolveFunction(n, FunctionTypeFunction); err != nil {
+			return nil, err
+		}
+		return nil, nil
 
-func (r *Resolver) VisitGetExpression(expression *GetExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Object)
-	if err != nil {
-		return
-	}
+	case *ReturnStatement:
+		if r.CurrentFunction == FunctionTypeNone {
+			r.Interpreter.Diagnostics.Add(PhaseResolve, n.Keyword, "Can't return from top-level code.")
+		}
+		return r, nil
+
+	case *VarStatement:
+		r.declare(n.Name)
+		if n.Initializer != nil {
+			if err := Walk(r, n.Initializer); err != nil {
+				return nil, err
+			}
+		}
+		r.define(n.Name)
+		return nil, nil
+
+	case *ClassStatement:
+		r.declare(n.Name)
+		r.define(n.Name)
+
+		if n.Superclass != nil {
+			if n.Superclass.Name.Lexeme == n.Name.Lexeme {
+				r.Interpreter.Diagnostics.Add(PhaseResolve, n.Superclass.Name, "A class can't inherit from itself.")
+			}
+			r.resolveLocal(n.Superclass, n.Superclass.Name)
+
+			// enclosing scope holding 'super', matching the nested
+			// Environment Interpreter.VisitClassStatement builds at runtime
+			r.beginScope()
+			superScope, _ := r.Scopes.Peek()
+			superScope["super"] = true
+		}
 
-	return
-}
+		r.beginScope()
+		thisScope, _ := r.Scopes.Peek()
+		thisScope["this"] = true
 
-func (r *Resolver) VisitSetExpression(expression *SetExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Value)
-	if err != nil {
-		return
-	}
+		for _, method := range n.Methods {
+			if err := r.resolveFunction(method, FunctionTypeMethod); err != nil {
+				return nil, err
+			}
+		}
 
-	err = r.resolveExpression(expression.Object)
-	if err != nil {
-		return
-	}
+		r.endScope()
+		if n.Superclass != nil {
+			r.endScope()
+		}
+		return nil, nil
 
-	return
-}
+	case *ForeachStatement:
+		if err := Walk(r, n.Iterable); err != nil {
+			return nil, err
+		}
 
-func (r *Resolver) VisitThisExpression(expression *ThisExpression) (value Value, err error) {
-	r.resolveLocal(expression, expression.Keyword)
-	return
-}
+		r.beginScope()
+		r.declare(n.Name)
+		r.define(n.Name)
+		if err := Walk(r, n.Body); err != nil {
+			return nil, err
+		}
+		r.endScope()
+		return nil, nil
 
-func (r *Resolver) VisitGroupingExpression(expression *GroupingExpression) (value Value, err error) {
-	err = r.resolveExpression(expression.Expression)
-	if err != nil {
-		return
-	}
+	case *AssignExpression:
+		if err := Walk(r, n.Value); err != nil {
+			return nil, err
+		}
+		r.resolveLocal(n, n.Name)
+		return nil, nil
+
+	case *VariableExpression:
+		if !r.Scopes.IsEmpty() {
+			// is the variable being accessed from its own initializer?
+			// (declared but not defined)
+			scope, _ := r.Scopes.Peek()
+			v, ok := scope[n.Name.Lexeme]
+			if ok && !v {
+				r.Interpreter.Diagnostics.Add(PhaseResolve, n.Name, "Can't read local variable in its own initializer.")
+			}
+		}
+		r.resolveLocal(n, n.Name)
+		return nil, nil
 
-	return
-}
+	case *ThisExpression:
+		r.resolveLocal(n, n.Keyword)
+		return nil, nil
 
-func (r *Resolver) VisitLiteralExpression(expression *LiteralExpression) (value Value, err error) {
-	return
-}
+	case *SuperExpression:
+		r.resolveLocal(n, n.Keyword)
+		return nil, nil
 
-func (r *Resolver) VisitVariableExpression(expression *VariableExpression) (value Value, err error) {
-	if !r.Scopes.IsEmpty() {
-		// is the variable being accessed from its own initializer?
-		// (declared but not defined)
-		scope, _ := r.Scopes.Peek()
-		v, ok := scope[expression.Name.Lexeme]
-		if ok && !v {
-			reportError(expression.Name, "Can't read local variable in its own initializer.")
-		}
+	default:
+		return r, nil
 	}
-
-	r.resolveLocal(expression, expression.Name)
-	return
-}
-
-func (r *Resolver) resolveExpression(expression Expression) error {
-	_, err := expression.AcceptValue(r)
-	return err
 }
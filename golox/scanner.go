@@ -7,22 +7,28 @@ import (
 )
 
 var keywords = map[string]TokenType{
-	"and":    And,
-	"or":     Or,
-	"if":     If,
-	"else":   Else,
-	"class":  Class,
-	"super":  Super,
-	"this":   This,
-	"true":   True,
-	"false":  False,
-	"fun":    Fun,
-	"for":    For,
-	"while":  While,
-	"nil":    Nil,
-	"print":  Print,
-	"return": Return,
-	"var":    Var,
+	"and":      And,
+	"or":       Or,
+	"if":       If,
+	"else":     Else,
+	"class":    Class,
+	"super":    Super,
+	"this":     This,
+	"true":     True,
+	"false":    False,
+	"fun":      Fun,
+	"for":      For,
+	"while":    While,
+	"nil":      Nil,
+	"print":    Print,
+	"return":   Return,
+	"var":      Var,
+	"import":   Import,
+	"foreach":  Foreach,
+	"in":       In,
+	"as":       As,
+	"break":    Break,
+	"continue": Continue,
 }
 
 type Scanner struct {
@@ -33,6 +39,14 @@ type Scanner struct {
 	Start   uint `json:"start"`
 	Current uint `json:"current"`
 	Line    uint `json:"line"`
+
+	// LineStart is the Offset of the first character of Line, so addToken
+	// can compute a token's Column without rescanning the line.
+	LineStart uint `json:"lineStart"`
+
+	File *File `json:"-"`
+
+	Diagnostics *Diagnostics `json:"-"`
 }
 
 func (s *Scanner) ScanTokens() {
@@ -57,6 +71,7 @@ func (s *Scanner) reset() {
 	s.Start = 0
 	s.Current = 0
 	s.Line = 1
+	s.LineStart = 0
 }
 
 func (s *Scanner) scanToken() bool {
@@ -71,6 +86,10 @@ func (s *Scanner) scanToken() bool {
 		s.addToken(LeftBrace)
 	case '}':
 		s.addToken(RightBrace)
+	case '[':
+		s.addToken(LeftBracket)
+	case ']':
+		s.addToken(RightBracket)
 	case ',':
 		s.addToken(Comma)
 	case '.':
@@ -83,6 +102,10 @@ func (s *Scanner) scanToken() bool {
 		s.addToken(Semicolon)
 	case '*':
 		s.addToken(Star)
+	case '?':
+		s.addToken(Question)
+	case ':':
+		s.addToken(Colon)
 
 	// special handling for slash (division and comments)
 	case '/':
@@ -130,6 +153,7 @@ func (s *Scanner) scanToken() bool {
 	// line counter
 	case '\n':
 		s.Line++
+		s.LineStart = s.Current
 
 	// EOF
 	case 0:
@@ -197,6 +221,8 @@ func (s *Scanner) singleComment() {
 }
 
 func (s *Scanner) multiComment() {
+	startLine, startColumn := s.Line, s.column()
+
 	for {
 		ch := s.peek()
 		if (ch == '*' && s.peekNext() == '/') || ch == 0 {
@@ -205,13 +231,14 @@ func (s *Scanner) multiComment() {
 
 		if ch == '\n' {
 			s.Line++
+			s.LineStart = s.Current + 1
 		}
 
 		s.advance()
 	}
 
 	if s.isAtEnd() {
-		s.error(s.Line, fmt.Sprintf("Unterminated multi-line comment '%s'", s.lexeme()))
+		s.errorAt(startLine, startColumn, fmt.Sprintf("Unterminated multi-line comment '%s'", s.lexeme()))
 		return
 	}
 
@@ -220,8 +247,17 @@ func (s *Scanner) multiComment() {
 	s.advance()
 }
 
+// stringLiteral scans a (possibly multi-line) string literal, decoding
+// backslash escapes as it goes, and splitting on any "${...}" interpolation
+// into the token sequence "prefix" + ( expr ) + "suffix" + ... - a plain
+// string with no interpolation still comes out as a single String token,
+// unchanged from before escapes/interpolation existed.
 func (s *Scanner) stringLiteral() {
-	// TODO: handle escape characters
+	startLine, startColumn := s.Line, s.column()
+
+	var value []rune
+	segmentStart := s.Start
+	interpolated := false
 
 	for {
 		ch := s.peek()
@@ -229,25 +265,161 @@ func (s *Scanner) stringLiteral() {
 			break
 		}
 
+		if ch == '$' && s.peekNext() == '{' {
+			segmentEnd := s.Current
+			s.advance() // '$'
+			s.advance() // '{'
+			exprStart := s.Current
+
+			tokenType := TemplateStart
+			if interpolated {
+				tokenType = TemplatePart
+			}
+			s.addTokenAt(tokenType, segmentStart, segmentEnd, NewStringLiteral(string(value)))
+			s.addTokenAt(Plus, segmentEnd, segmentEnd, LiteralValue{})
+			s.addTokenAt(LeftParen, segmentEnd, exprStart, LiteralValue{})
+
+			interpolated = true
+			s.scanInterpolatedExpression()
+
+			rParenEnd := s.Current
+			s.addTokenAt(RightParen, rParenEnd-1, rParenEnd, LiteralValue{})
+			s.addTokenAt(Plus, rParenEnd, rParenEnd, LiteralValue{})
+
+			value = nil
+			segmentStart = s.Current
+			continue
+		}
+
+		if ch == '\\' {
+			if decoded, ok := s.scanEscape(); ok {
+				value = append(value, decoded...)
+			}
+			continue
+		}
+
 		// allow multiline strings
 		if ch == '\n' {
 			s.Line++
+			s.LineStart = s.Current + 1
 		}
 
+		value = append(value, ch)
 		s.advance()
 	}
 
 	if s.isAtEnd() {
-		s.error(s.Line, fmt.Sprintf("Unterminated string literal '%s'", s.lexeme()))
+		s.errorAt(startLine, startColumn, fmt.Sprintf("Unterminated string literal '%s'", s.lexeme()))
 		return
 	}
 
 	// consume the closing '"'
 	s.advance()
 
-	// trim the quotes from the value
-	value := string(s.source[s.Start+1 : s.Current-1])
-	s.addTokenLiteral(String, NewStringLiteral(value))
+	tokenType := String
+	if interpolated {
+		tokenType = TemplateEnd
+	}
+	s.addTokenAt(tokenType, segmentStart, s.Current, NewStringLiteral(string(value)))
+}
+
+// scanEscape consumes a backslash escape starting at the current position
+// (s.peek() == '\\') and returns its decoded rune(s). ok is false if the
+// escape wasn't recognized or malformed a \x/\u payload - a Diagnostic has
+// already been added, and the scanner has consumed enough of the bad
+// escape to keep making forward progress and find any further problems in
+// the same string.
+func (s *Scanner) scanEscape() (decoded []rune, ok bool) {
+	escapeLine, escapeColumn := s.Line, s.Current-s.LineStart+1
+
+	s.advance() // '\\'
+	ch := s.advance()
+
+	switch ch {
+	case 'n':
+		return []rune{'\n'}, true
+	case 't':
+		return []rune{'\t'}, true
+	case 'r':
+		return []rune{'\r'}, true
+	case '\\':
+		return []rune{'\\'}, true
+	case '"':
+		return []rune{'"'}, true
+	case '0':
+		return []rune{0}, true
+	case 'x':
+		return s.scanEscapeDigits(escapeLine, escapeColumn, 2, `\xHH`)
+	case 'u':
+		return s.scanEscapeDigits(escapeLine, escapeColumn, 4, `\uHHHH`)
+	default:
+		s.errorAt(escapeLine, escapeColumn, fmt.Sprintf("Invalid escape sequence '\\%c'", ch))
+		return nil, false
+	}
+}
+
+// scanEscapeDigits consumes count hex digits for a \xHH or \uHHHH escape
+// and decodes them as a single rune, anchoring any error at the escape's
+// own line/column (line, column) rather than the string's opening quote.
+func (s *Scanner) scanEscapeDigits(line uint, column uint, count int, form string) (decoded []rune, ok bool) {
+	digits := make([]rune, 0, count)
+	for i := 0; i < count; i++ {
+		ch := s.peek()
+		if !isHexDigit(ch) {
+			s.errorAt(line, column, fmt.Sprintf("Invalid %s escape sequence: expected %d hex digits", form, count))
+			return nil, false
+		}
+		digits = append(digits, ch)
+		s.advance()
+	}
+
+	value, err := strconv.ParseUint(string(digits), 16, 32)
+	if err != nil {
+		s.errorAt(line, column, fmt.Sprintf("Invalid %s escape sequence: %s", form, err.Error()))
+		return nil, false
+	}
+	return []rune{rune(value)}, true
+}
+
+func isHexDigit(ch rune) bool {
+	return unicode.IsDigit(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// scanInterpolatedExpression scans ordinary tokens - reusing scanToken
+// exactly as if this were top-level source - for the expression inside a
+// "${...}" interpolation, stopping at (and consuming) the matching '}'.
+// depth tracks nested '{'/'}' from a map literal inside the expression, so
+// only the interpolation's own closing brace ends the scan.
+func (s *Scanner) scanInterpolatedExpression() {
+	depth := 0
+
+	for {
+		ch := s.peek()
+		if ch == 0 {
+			s.errorAt(s.Line, s.column(), "Unterminated '${' interpolation")
+			return
+		}
+
+		if ch == '}' && depth == 0 {
+			s.advance()
+			return
+		}
+
+		s.Start = s.Current
+		if !s.scanToken() {
+			return
+		}
+
+		if len(s.Tokens) == 0 {
+			continue
+		}
+		switch s.Tokens[len(s.Tokens)-1].Type {
+		case LeftBrace:
+			depth++
+		case RightBrace:
+			depth--
+		}
+	}
 }
 
 func (s *Scanner) numberLiteral() {
@@ -303,6 +475,11 @@ func (s *Scanner) lexeme() string {
 	return string(s.source[s.Start:end])
 }
 
+// column returns the 1-based column of Start on the current line.
+func (s *Scanner) column() uint {
+	return s.Start - s.LineStart + 1
+}
+
 func (s *Scanner) addToken(tokenType TokenType) {
 	s.addTokenLiteral(tokenType, LiteralValue{})
 }
@@ -313,6 +490,28 @@ func (s *Scanner) addTokenLiteral(tokenType TokenType, literal LiteralValue) {
 		Lexeme:  s.lexeme(),
 		Literal: literal,
 		Line:    s.Line,
+		Column:  s.column(),
+		Offset:  s.Start,
+		Length:  s.Current - s.Start,
+		File:    s.File,
+	})
+}
+
+// addTokenAt appends a token spanning source[start:end), bypassing
+// Lexeme/Offset/Length's usual derivation from Start/Current. stringLiteral
+// uses it for the pieces - literal segments, and the synthetic
+// Plus/LeftParen/RightParen around an interpolated expression - that don't
+// align with a single contiguous Start..Current scan.
+func (s *Scanner) addTokenAt(tokenType TokenType, start uint, end uint, literal LiteralValue) {
+	s.Tokens = append(s.Tokens, &Token{
+		Type:    tokenType,
+		Lexeme:  string(s.source[start:end]),
+		Literal: literal,
+		Line:    s.Line,
+		Column:  start - s.LineStart + 1,
+		Offset:  start,
+		Length:  end - start,
+		File:    s.File,
 	})
 }
 
@@ -321,13 +520,22 @@ func (s *Scanner) isAtEnd() bool {
 }
 
 func (s *Scanner) error(line uint, message string) {
-	report(line, "", message)
+	s.errorAt(line, 0, message)
+}
+
+// errorAt reports message anchored at line/column, e.g. the opening quote
+// of a string an "unterminated" error points back to rather than the
+// current (end-of-file) position.
+func (s *Scanner) errorAt(line uint, column uint, message string) {
+	s.Diagnostics.AddLine(PhaseScan, s.File, line, column, message)
 }
 
-func NewScanner(source string) Scanner {
+func NewScanner(source string, file *File, diagnostics *Diagnostics) Scanner {
 	return Scanner{
-		Tokens: []*Token{},
-		source: []rune(source),
-		Line:   1,
+		Tokens:      []*Token{},
+		source:      []rune(source),
+		Line:        1,
+		File:        file,
+		Diagnostics: diagnostics,
 	}
 }
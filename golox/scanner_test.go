@@ -2,20 +2,20 @@ package main
 
 import "testing"
 
-func scan(source string) Scanner {
-	hadError = false
+func scan(source string) (Scanner, *Diagnostics) {
+	diagnostics := NewDiagnostics()
 
-	scanner := NewScanner(source, true)
+	scanner := NewScanner(source, nil, diagnostics)
 	scanner.ScanTokens()
 
-	return scanner
+	return scanner, diagnostics
 }
 
 func simpleScanTest(t *testing.T, source string, expectedTokens []TokenType, expectedLines uint) Scanner {
 	expectedTokens = append(expectedTokens, EOF)
 
-	scanner := scan(source)
-	if hadError {
+	scanner, diagnostics := scan(source)
+	if diagnostics.HasErrors() {
 		t.Fatal("Unexpected parse error")
 	}
 
@@ -41,8 +41,8 @@ func simpleScanTest(t *testing.T, source string, expectedTokens []TokenType, exp
 }
 
 func simpleErrorScanTest(t *testing.T, source string) Scanner {
-	scanner := scan(source)
-	if !hadError {
+	scanner, diagnostics := scan(source)
+	if !diagnostics.HasErrors() {
 		t.Fatal("Expected parse error")
 	}
 
@@ -85,7 +85,7 @@ func TestIdentifier(t *testing.T) {
 }
 
 func TestKeyword(t *testing.T) {
-	source := "and or if else class super this true false fun for while nil print return var"
+	source := "and or if else class super this true false fun for while nil print return var import"
 	expectedTokens := []TokenType{
 		And, Or,
 		If, Else,
@@ -97,6 +97,16 @@ func TestKeyword(t *testing.T) {
 		Print,
 		Return,
 		Var,
+		Import,
+	}
+
+	simpleScanTest(t, source, expectedTokens, 1)
+}
+
+func TestBreakContinueKeywords(t *testing.T) {
+	source := "break continue"
+	expectedTokens := []TokenType{
+		Break, Continue,
 	}
 
 	simpleScanTest(t, source, expectedTokens, 1)
@@ -128,6 +138,48 @@ func TestNumber(t *testing.T) {
 	simpleScanTest(t, source, expectedTokens, 1)
 }
 
+func TestStringEscapes(t *testing.T) {
+	source := `"a\nb\tc\\d\"e\0f\x41B"`
+	scanner := simpleScanTest(t, source, []TokenType{String}, 1)
+
+	got := scanner.Tokens[0].Literal.StringValue
+	want := "a\nb\tc\\d\"e\x00f\x41B"
+	if got != want {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+
+	simpleErrorScanTest(t, `"bad \q escape"`)
+	simpleErrorScanTest(t, `"unterminated hex \x4"`)
+}
+
+func TestStringInterpolation(t *testing.T) {
+	source := `"hello ${name}, you are ${1 + 2} today"`
+	expectedTokens := []TokenType{
+		TemplateStart, Plus, LeftParen, Identifier, RightParen,
+		Plus, TemplatePart, Plus, LeftParen, Number, Plus, Number, RightParen,
+		Plus, TemplateEnd,
+	}
+
+	scanner := simpleScanTest(t, source, expectedTokens, 1)
+
+	if scanner.Tokens[0].Literal.StringValue != "hello " {
+		t.Fatalf("Expected prefix %q, got %q", "hello ", scanner.Tokens[0].Literal.StringValue)
+	}
+	if scanner.Tokens[6].Literal.StringValue != ", you are " {
+		t.Fatalf("Expected middle %q, got %q", ", you are ", scanner.Tokens[6].Literal.StringValue)
+	}
+	if scanner.Tokens[14].Literal.StringValue != " today" {
+		t.Fatalf("Expected suffix %q, got %q", " today", scanner.Tokens[14].Literal.StringValue)
+	}
+
+	source = "\"a${\nb\n}c\""
+	expectedTokens = []TokenType{
+		TemplateStart, Plus, LeftParen, Identifier, RightParen, Plus, TemplateEnd,
+	}
+
+	simpleScanTest(t, source, expectedTokens, 3)
+}
+
 func TestSingleComment(t *testing.T) {
 	source := `// this is a full line comment
 	test // this is a comment after a line`
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// interpreterSnapshot is the serialized form of an Interpreter's scope
+// chain, not the full struct: Locals is keyed by Expression pointer
+// identity, which can't survive a restart (the restored program is
+// re-parsed into brand new nodes), so it isn't part of the wire format -
+// re-resolving the source after LoadSnapshot rebuilds an equivalent Locals
+// for the new tree instead. Function/class/instance values still don't
+// round-trip - see Value.MarshalJSON.
+type interpreterSnapshot struct {
+	Environment   *Environment `json:"environment"`
+	NextStatement int          `json:"nextStatement"`
+	Debug         bool         `json:"debug"`
+	Filename      string       `json:"filename,omitempty"`
+}
+
+// Snapshot serializes the interpreter's current scope chain to JSON, so a
+// long running script can be paused and resumed later, possibly on a
+// different host, via LoadSnapshot. It only makes sense between top-level
+// statements, where Environment is always Globals (executeBlock restores
+// it on the way out of every nested scope) - calling it from inside a
+// native function mid-statement would otherwise silently snapshot the
+// wrong scope.
+func (i *Interpreter) Snapshot() ([]byte, error) {
+	if i.Environment != i.Globals {
+		return nil, fmt.Errorf("snapshot: can only save between top-level statements, not from inside a block or call")
+	}
+
+	var filename string
+	if i.File != nil {
+		filename = i.File.Name
+	}
+
+	// Natives are reinstalled by NewInterpreter on load, same as they are
+	// on every fresh Interpreter, so they're left out here rather than
+	// hitting Value.MarshalJSON's "function values can't be serialized"
+	// error for something LoadSnapshot already puts back regardless.
+	globals := NewEnvironment()
+	for name, value := range i.Globals.Values {
+		if value.Type == ValueTypeFunction && isNativeCallable(value.FunctionValue) {
+			continue
+		}
+		globals.Values[name] = value
+	}
+
+	snapshot := interpreterSnapshot{
+		Environment:   globals,
+		NextStatement: i.NextStatement,
+		Debug:         i.Debug,
+		Filename:      filename,
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// LoadSnapshot reconstructs an Interpreter from a Snapshot, re-installing
+// native functions the same way NewInterpreter does before restoring the
+// saved global values over them. The caller still needs to re-scan, parse,
+// and resolve the original source against the returned Interpreter - that
+// rebuilds a Locals map that matches the freshly parsed tree - before
+// calling Interpret on statements[interpreter.NextStatement:] to pick up
+// where the snapshot left off.
+func LoadSnapshot(data []byte) (*Interpreter, error) {
+	var snapshot interpreterSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+
+	var file *File
+	if snapshot.Filename != "" {
+		file = NewFile(snapshot.Filename)
+	}
+
+	interpreter := NewInterpreter(snapshot.Debug, file)
+	interpreter.NextStatement = snapshot.NextStatement
+
+	if snapshot.Environment != nil {
+		for name, value := range snapshot.Environment.Values {
+			interpreter.Globals.Define(name, value)
+		}
+	}
+
+	return &interpreter, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSnapshotResume covers the save/restore round trip this chunk0-5 fix
+// adds: snapshot after the first two statements, reload into a fresh
+// interpreter, then resume from NextStatement and confirm the global state
+// and remaining output match running the whole program in one pass.
+func TestSnapshotResume(t *testing.T) {
+	source := `
+var x = 1;
+x = x + 1;
+print x;
+`
+	interpreter := NewInterpreter(false, nil)
+
+	scanner := NewScanner(source, interpreter.File, interpreter.Diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, interpreter.Diagnostics)
+	statements, _ := parser.Parse()
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	resolver := NewResolver(&interpreter)
+	statements = resolver.Resolve(statements)
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("resolve failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	interpreter.Interpret(statements[:2])
+	if interpreter.NextStatement != 2 {
+		t.Fatalf("expected NextStatement 2, got %d", interpreter.NextStatement)
+	}
+
+	data, err := interpreter.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot failed: %s", err)
+	}
+
+	resumed, err := LoadSnapshot(data)
+	if err != nil {
+		t.Fatalf("load snapshot failed: %s", err)
+	}
+
+	if resumed.NextStatement != 2 {
+		t.Fatalf("expected resumed NextStatement 2, got %d", resumed.NextStatement)
+	}
+
+	var output bytes.Buffer
+	resumed.Output = &output
+
+	resumed.Interpret(statements[resumed.NextStatement:])
+	if resumed.Diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output.String() != "2\n" {
+		t.Fatalf("unexpected output %q", output.String())
+	}
+}
+
+// TestSnapshotRejectsNonGlobalScope covers Snapshot's guard against being
+// called anywhere but between top-level statements, where Environment is
+// guaranteed to be Globals.
+func TestSnapshotRejectsNonGlobalScope(t *testing.T) {
+	interpreter := NewInterpreter(false, nil)
+	interpreter.Environment = NewEnvironmentScope(interpreter.Globals)
+
+	if _, err := interpreter.Snapshot(); err == nil {
+		t.Fatal("expected an error snapshotting from a non-global scope")
+	}
+}
+
+// TestSnapshotRejectsFunctionValues covers Value.MarshalJSON failing loudly
+// on a global that holds a function, rather than silently dropping its
+// closure the way it used to.
+func TestSnapshotRejectsFunctionValues(t *testing.T) {
+	source := `fun f() {}`
+
+	interpreter := NewInterpreter(false, nil)
+
+	scanner := NewScanner(source, interpreter.File, interpreter.Diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, interpreter.Diagnostics)
+	statements, _ := parser.Parse()
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	resolver := NewResolver(&interpreter)
+	statements = resolver.Resolve(statements)
+	if interpreter.Diagnostics.HasErrors() {
+		t.Fatalf("resolve failed: %s", interpreter.Diagnostics.Format(source))
+	}
+
+	interpreter.Interpret(statements)
+
+	if _, err := interpreter.Snapshot(); err == nil {
+		t.Fatal("expected an error snapshotting a global function value")
+	}
+}
@@ -6,6 +6,10 @@ func (s *Stack[T]) IsEmpty() bool {
 	return len(*s) == 0
 }
 
+func (s *Stack[T]) Size() int {
+	return len(*s)
+}
+
 func (s *Stack[T]) Push(v T) {
 	*s = append(*s, v)
 }
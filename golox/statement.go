@@ -17,6 +17,11 @@ type FunctionStatement struct {
 	Name   *Token
 	Params []*Token
 	Body   []Statement
+
+	// optional static type annotations; ParamTypes is parallel to Params
+	// and either slot may be nil when the parameter is untyped
+	ParamTypes []*Token
+	ReturnType *Token
 }
 
 func (e *FunctionStatement) Accept(visitor StatementVisitor) (*Value, error) {
@@ -43,6 +48,9 @@ func (e *ReturnStatement) Accept(visitor StatementVisitor) (*Value, error) {
 type VarStatement struct {
 	Name        *Token
 	Initializer Expression
+
+	// optional static type annotation, e.g. `var x: number = 1;`
+	Type *Token
 }
 
 func (e *VarStatement) Accept(visitor StatementVisitor) (*Value, error) {
@@ -71,6 +79,11 @@ func (e *IfStatement) Accept(visitor StatementVisitor) (*Value, error) {
 type WhileStatement struct {
 	Condition Expression
 	Body      Statement
+
+	// optional loop label, e.g. the `outer` in `outer: while (...) { ... }`
+	// - lets a break/continue elsewhere in Body name this loop specifically
+	// instead of unwinding to the nearest enclosing one
+	Label *Token
 }
 
 func (e *WhileStatement) Accept(visitor StatementVisitor) (*Value, error) {
@@ -79,6 +92,9 @@ func (e *WhileStatement) Accept(visitor StatementVisitor) (*Value, error) {
 
 type BreakStatement struct {
 	Keyword *Token
+
+	// optional target loop label, e.g. the `outer` in `break outer;`
+	Label *Token
 }
 
 func (e *BreakStatement) Accept(visitor StatementVisitor) (*Value, error) {
@@ -87,12 +103,50 @@ func (e *BreakStatement) Accept(visitor StatementVisitor) (*Value, error) {
 
 type ContinueStatement struct {
 	Keyword *Token
+
+	// optional target loop label, e.g. the `outer` in `continue outer;`
+	Label *Token
 }
 
 func (e *ContinueStatement) Accept(visitor StatementVisitor) (*Value, error) {
 	return visitor.VisitContinueStatement(e)
 }
 
+type ClassStatement struct {
+	Name       *Token
+	Superclass *VariableExpression
+	Methods    []*FunctionStatement
+}
+
+func (e *ClassStatement) Accept(visitor StatementVisitor) (*Value, error) {
+	return visitor.VisitClassStatement(e)
+}
+
+type ImportStatement struct {
+	Keyword *Token
+	Path    *Token
+
+	// Alias is the optional `as name` binding; nil means the module binds
+	// under its default name (the stdlib module name, or the imported
+	// file's base name without extension).
+	Alias *Token
+}
+
+func (e *ImportStatement) Accept(visitor StatementVisitor) (*Value, error) {
+	return visitor.VisitImportStatement(e)
+}
+
+type ForeachStatement struct {
+	Keyword  *Token
+	Name     *Token
+	Iterable Expression
+	Body     Statement
+}
+
+func (e *ForeachStatement) Accept(visitor StatementVisitor) (*Value, error) {
+	return visitor.VisitForeachStatement(e)
+}
+
 type StatementVisitor interface {
 	VisitExpressionStatement(statement *ExpressionStatement) (*Value, error)
 	VisitFunctionStatement(statement *FunctionStatement) (*Value, error)
@@ -104,4 +158,7 @@ type StatementVisitor interface {
 	VisitWhileStatement(statement *WhileStatement) (*Value, error)
 	VisitBreakStatement(statement *BreakStatement) (*Value, error)
 	VisitContinueStatement(statement *ContinueStatement) (*Value, error)
+	VisitClassStatement(statement *ClassStatement) (*Value, error)
+	VisitImportStatement(statement *ImportStatement) (*Value, error)
+	VisitForeachStatement(statement *ForeachStatement) (*Value, error)
 }
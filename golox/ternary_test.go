@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+// TestTernary covers `a ? b : c` end to end - it couldn't even scan
+// until chunk0-2's fix added the Question/Colon tokens, despite the
+// Pratt parser and the ast.Modify desugaring pass already expecting them.
+func TestTernary(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+print 1 > 0 ? "y" : "n";
+print 1 < 0 ? "y" : "n";
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "y\nn\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+// TestTernaryNesting covers the right-associative nesting parseTernary
+// documents: `a ? b : c ? d : e` should parse as `a ? b : (c ? d : e)`.
+func TestTernaryNesting(t *testing.T) {
+	output, diagnostics := interpretProgram(t, `
+var x = 2;
+print x == 1 ? "one" : x == 2 ? "two" : "other";
+`)
+	if diagnostics.HasRuntimeErrors() {
+		t.Fatalf("unexpected runtime error")
+	}
+
+	if output != "two\n" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
@@ -37,6 +37,11 @@ func (t TokenType) String() string {
 		"Print",
 		"Return",
 		"Var",
+		"Import",
+		"LeftBracket", "RightBracket",
+		"Foreach", "In",
+		"As",
+		"TemplateStart", "TemplatePart", "TemplateEnd",
 	}[t]
 }
 
@@ -92,6 +97,27 @@ const (
 	Print    TokenType = 40
 	Return   TokenType = 41
 	Var      TokenType = 42
+	Import   TokenType = 43
+
+	LeftBracket  TokenType = 44
+	RightBracket TokenType = 45
+
+	Foreach TokenType = 46
+	In      TokenType = 47
+
+	As TokenType = 48
+
+	// TemplateStart/TemplatePart/TemplateEnd mark the segments of an
+	// interpolated string ("... ${expr} ..."), which the scanner splits
+	// into a string + expr + string + ... token sequence - see
+	// Scanner.stringLiteral. TemplateStart carries the literal text before
+	// the first "${", TemplatePart the literal text between two
+	// interpolations, and TemplateEnd the literal text after the last "}".
+	// A plain (non-interpolated) string is still scanned as a single
+	// String token, unchanged.
+	TemplateStart TokenType = 49
+	TemplatePart  TokenType = 50
+	TemplateEnd   TokenType = 51
 )
 
 type LiteralType int
@@ -160,11 +186,39 @@ func NewBoolLiteral(value bool) LiteralValue {
 	}
 }
 
+// File identifies the source a Token was scanned from - a script's path on
+// disk, or a synthetic name like "<stdin>" for the REPL and debugger
+// evaluate() calls. Diagnostics use it to print "name:line:col" instead of
+// a bare line number, so an editor/LSP integration (or a human following
+// an import chain) knows which file an error actually belongs to.
+type File struct {
+	Name string
+}
+
+func NewFile(name string) *File {
+	return &File{Name: name}
+}
+
 type Token struct {
 	Type    TokenType    `json:"type"`
 	Lexeme  string       `json:"lexeme,omitempty"`
 	Literal LiteralValue `json:"literal,omitempty"`
 	Line    uint         `json:"line"`
+
+	// Column is the 1-based column of the first character of Lexeme on
+	// Line. Offset and Length give the same span as 0-based indices into
+	// the full source string, for callers (an editor extension) that want
+	// to slice the source directly instead of re-deriving it from
+	// Line/Column.
+	Column uint `json:"column,omitempty"`
+	Offset uint `json:"offset,omitempty"`
+	Length uint `json:"length,omitempty"`
+
+	// File is nil for tokens synthesized by a pass other than Scanner
+	// (the PEG parser's action bindings, the VM's synthetic lookup
+	// tokens) - Diagnostic.header falls back to the old "[line N]" form
+	// for those instead of "name:line:col".
+	File *File `json:"file,omitempty"`
 }
 
 func (t Token) String() string {
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// declInfo is one node of the dependency graph toposort builds over a
+// program's top-level statements. Every statement gets a node, so its
+// position relative to other unconstrained statements is still decided,
+// but only a FunctionStatement, ClassStatement, or VarStatement has a
+// name other statements can depend on.
+type declInfo struct {
+	statement Statement
+	refs      map[string]bool
+}
+
+// declRefCollector walks a single top-level statement (ast_walk.go) and
+// records every identifier it reads that isn't bound by a scope nested
+// within that same statement - a parameter, a local var, a foreach
+// variable, a nested function/class name - so a local merely sharing a
+// top-level declaration's name doesn't fabricate a dependency edge on
+// that declaration. It's still an overapproximation of what the
+// statement actually depends on once free, good enough to order
+// declarations by, not a full data-flow analysis - the same trade-off
+// UnusedVariableChecker (ast_checks.go) makes in the opposite direction.
+type declRefCollector struct {
+	refs  map[string]bool
+	bound Stack[Scope]
+}
+
+// bind records name as locally bound in the innermost pushed scope, if
+// any. At the root of a walk (no scope pushed yet) it's a no-op: the
+// statement being collected is itself one of the top-level declarations
+// toposort is ordering, so its own name was never meant to shadow itself.
+func (c *declRefCollector) bind(name string) {
+	if c.bound.IsEmpty() {
+		return
+	}
+	scope, _ := c.bound.Peek()
+	scope[name] = true
+}
+
+func (c *declRefCollector) isBound(name string) bool {
+	for idx := len(c.bound) - 1; idx >= 0; idx-- {
+		if c.bound[idx][name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *declRefCollector) Visit(node Node) (Visitor, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+
+	case *VariableExpression:
+		if !c.isBound(n.Name.Lexeme) {
+			c.refs[n.Name.Lexeme] = true
+		}
+		return nil, nil
+
+	case *FunctionStatement:
+		if name, ok := declName(n); ok {
+			c.bind(name)
+		}
+
+		scope := Scope{}
+		for _, param := range n.Params {
+			scope[param.Lexeme] = true
+		}
+
+		c.bound.Push(scope)
+		for _, statement := range n.Body {
+			if err := Walk(c, statement); err != nil {
+				return nil, err
+			}
+		}
+		c.bound.Pop()
+		return nil, nil
+
+	case *VarStatement:
+		if n.Initializer != nil {
+			if err := Walk(c, n.Initializer); err != nil {
+				return nil, err
+			}
+		}
+		c.bind(n.Name.Lexeme)
+		return nil, nil
+
+	case *BlockStatement:
+		c.bound.Push(Scope{})
+		for _, statement := range n.Statements {
+			if err := Walk(c, statement); err != nil {
+				return nil, err
+			}
+		}
+		c.bound.Pop()
+		return nil, nil
+
+	case *ForeachStatement:
+		if err := Walk(c, n.Iterable); err != nil {
+			return nil, err
+		}
+
+		c.bound.Push(Scope{n.Name.Lexeme: true})
+		if err := Walk(c, n.Body); err != nil {
+			return nil, err
+		}
+		c.bound.Pop()
+		return nil, nil
+
+	case *ClassStatement:
+		if n.Superclass != nil && !c.isBound(n.Superclass.Name.Lexeme) {
+			c.refs[n.Superclass.Name.Lexeme] = true
+		}
+		if name, ok := declName(n); ok {
+			c.bind(name)
+		}
+
+		c.bound.Push(Scope{"this": true})
+		for _, method := range n.Methods {
+			if err := Walk(c, method); err != nil {
+				return nil, err
+			}
+		}
+		c.bound.Pop()
+		return nil, nil
+	}
+	return c, nil
+}
+
+func declRefs(statement Statement) map[string]bool {
+	c := &declRefCollector{refs: map[string]bool{}}
+	Walk(c, statement)
+	return c.refs
+}
+
+// declName returns the name a top-level FunctionStatement, ClassStatement,
+// or VarStatement binds, and whether statement is one of those three
+// kinds - the only top-level statements that contribute a name another
+// statement can depend on.
+func declName(statement Statement) (string, bool) {
+	switch s := statement.(type) {
+	case *FunctionStatement:
+		return s.Name.Lexeme, true
+	case *ClassStatement:
+		return s.Name.Lexeme, true
+	case *VarStatement:
+		return s.Name.Lexeme, true
+	}
+	return "", false
+}
+
+// toposort groups a program's top-level statements into strongly
+// connected components over the dependency graph their declarations form
+// - an edge from A to B whenever A references a name B declares - finds
+// those components with Tarjan, and orders the components so B's group
+// always comes before A's (Kahn, on the SCC condensation). A statement
+// that declares nothing, or references nothing declared in this same
+// list, gets its own singleton component and keeps its position relative
+// to any other unconstrained statement.
+//
+// Mutual recursion between functions or methods is fine in either order -
+// their bodies aren't evaluated until called, long after every top-level
+// name exists - so a multi-statement component is only a problem for a
+// VarStatement: unlike a function body, its initializer runs the instant
+// the declaration is reached, so a cycle running through one is reported
+// as a diagnostic instead of silently picking an order that would read an
+// undefined variable.
+func toposort(statements []Statement, diagnostics *Diagnostics) [][]Statement {
+	n := len(statements)
+	decls := make([]declInfo, n)
+	nameIndex := map[string]int{}
+
+	for i, statement := range statements {
+		decls[i] = declInfo{statement: statement, refs: declRefs(statement)}
+		if name, ok := declName(statement); ok {
+			// last declaration of a name wins, matching Environment.Define
+			nameIndex[name] = i
+		}
+	}
+
+	edges := make([][]int, n) // edges[i] are the statements i depends on
+	for i, d := range decls {
+		for name := range d.refs {
+			if j, ok := nameIndex[name]; ok && j != i {
+				edges[i] = append(edges[i], j)
+			}
+		}
+	}
+
+	sccs := tarjanSCC(edges)
+	reportIllegalCycles(sccs, decls, diagnostics)
+
+	order := kahnOrder(sccs, edges)
+
+	groups := make([][]Statement, 0, len(order))
+	for _, sccIdx := range order {
+		members := append([]int(nil), sccs[sccIdx]...)
+		sort.Ints(members)
+
+		group := make([]Statement, len(members))
+		for k, m := range members {
+			group[k] = decls[m].statement
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// reportIllegalCycles diagnoses every VarStatement caught in a multi-
+// statement SCC - there's no order toposort could pick that would make its
+// initializer see a value for whatever it depends on in the same cycle.
+func reportIllegalCycles(sccs [][]int, decls []declInfo, diagnostics *Diagnostics) {
+	for _, members := range sccs {
+		if len(members) < 2 {
+			continue
+		}
+
+		for _, m := range members {
+			varStatement, ok := decls[m].statement.(*VarStatement)
+			if !ok {
+				continue
+			}
+			diagnostics.Add(PhaseResolve, varStatement.Name, fmt.Sprintf("Can't resolve '%s': its initializer is part of a dependency cycle.", varStatement.Name.Lexeme))
+		}
+	}
+}
+
+// tarjanSCC finds the strongly connected components of the graph described
+// by edges (edges[i] are i's out-neighbors), using Tarjan's algorithm.
+func tarjanSCC(edges [][]int) [][]int {
+	n := len(edges)
+	t := &tarjanState{
+		edges:   edges,
+		index:   make([]int, n),
+		low:     make([]int, n),
+		onStack: make([]bool, n),
+	}
+	for i := range t.index {
+		t.index[i] = -1
+	}
+
+	for v := 0; v < n; v++ {
+		if t.index[v] == -1 {
+			t.connect(v)
+		}
+	}
+
+	return t.sccs
+}
+
+type tarjanState struct {
+	edges   [][]int
+	index   []int
+	low     []int
+	onStack []bool
+	stack   []int
+	counter int
+	sccs    [][]int
+}
+
+func (t *tarjanState) connect(v int) {
+	t.index[v] = t.counter
+	t.low[v] = t.counter
+	t.counter++
+	t.stack = append(t.stack, v)
+	t.onStack[v] = true
+
+	for _, w := range t.edges[v] {
+		if t.index[w] == -1 {
+			t.connect(w)
+			if t.low[w] < t.low[v] {
+				t.low[v] = t.low[w]
+			}
+		} else if t.onStack[w] && t.index[w] < t.low[v] {
+			t.low[v] = t.index[w]
+		}
+	}
+
+	if t.low[v] != t.index[v] {
+		return
+	}
+
+	var scc []int
+	for {
+		w := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[w] = false
+		scc = append(scc, w)
+		if w == v {
+			break
+		}
+	}
+	t.sccs = append(t.sccs, scc)
+}
+
+// kahnOrder orders sccs (as returned by tarjanSCC) so that, for every edge
+// i -> j in edges where i and j fall in different components, j's
+// component comes before i's. Ties - components with no ordering
+// constraint left between them - are broken by each component's smallest
+// original statement index, so a program with no forward references at
+// all comes back in its original order.
+func kahnOrder(sccs [][]int, edges [][]int) []int {
+	sccOf := make([]int, len(edges))
+	for sccIdx, members := range sccs {
+		for _, m := range members {
+			sccOf[m] = sccIdx
+		}
+	}
+
+	// condensation: an edge from the component a dependency lives in to
+	// the component that depends on it, so Kahn below yields dependencies
+	// before dependents.
+	adjacency := make([]map[int]bool, len(sccs))
+	indegree := make([]int, len(sccs))
+	for i := range adjacency {
+		adjacency[i] = map[int]bool{}
+	}
+	for i, deps := range edges {
+		for _, j := range deps {
+			from, to := sccOf[j], sccOf[i]
+			if from == to || adjacency[from][to] {
+				continue
+			}
+			adjacency[from][to] = true
+			indegree[to]++
+		}
+	}
+
+	minOriginal := make([]int, len(sccs))
+	for i, members := range sccs {
+		min := members[0]
+		for _, m := range members[1:] {
+			if m < min {
+				min = m
+			}
+		}
+		minOriginal[i] = min
+	}
+
+	var ready []int
+	for i, degree := range indegree {
+		if degree == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	order := make([]int, 0, len(sccs))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(a, b int) bool { return minOriginal[ready[a]] < minOriginal[ready[b]] })
+
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, next)
+
+		for to := range adjacency[next] {
+			indegree[to]--
+			if indegree[to] == 0 {
+				ready = append(ready, to)
+			}
+		}
+	}
+
+	return order
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func parseForToposort(source string) ([]Statement, *Diagnostics) {
+	diagnostics := NewDiagnostics()
+	scanner := NewScanner(source, nil, diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, diagnostics)
+	statements, _ := parser.Parse()
+	return statements, diagnostics
+}
+
+// TestToposortIgnoresShadowedLocalReferences covers a function whose own
+// local variable happens to share a name with another top-level
+// declaration: f's local x must not be treated as a reference to the
+// top-level x, or the two end up in a bogus dependency cycle with each
+// other even though f only ever reads its own local.
+func TestToposortIgnoresShadowedLocalReferences(t *testing.T) {
+	source := `
+var x = f();
+fun f() {
+	var x = 1;
+	return x;
+}
+`
+	statements, diagnostics := parseForToposort(source)
+	if diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %s", diagnostics.Format(source))
+	}
+
+	toposort(statements, diagnostics)
+
+	if diagnostics.HasErrors() {
+		t.Fatalf("expected no dependency-cycle diagnostics, got: %s", diagnostics.Format(source))
+	}
+}
+
+// TestToposortIgnoresShadowedParameter is the same shadowing case, but
+// through a parameter rather than a local var.
+func TestToposortIgnoresShadowedParameter(t *testing.T) {
+	source := `
+var x = f(1);
+fun f(x) {
+	return x;
+}
+`
+	statements, diagnostics := parseForToposort(source)
+	if diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %s", diagnostics.Format(source))
+	}
+
+	toposort(statements, diagnostics)
+
+	if diagnostics.HasErrors() {
+		t.Fatalf("expected no dependency-cycle diagnostics, got: %s", diagnostics.Format(source))
+	}
+}
+
+// TestToposortStillCatchesRealCycle makes sure tightening reference
+// collection to free variables didn't also blind toposort to a genuine
+// cycle between two vars.
+func TestToposortStillCatchesRealCycle(t *testing.T) {
+	source := `
+var x = y;
+var y = x;
+`
+	statements, diagnostics := parseForToposort(source)
+	if diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %s", diagnostics.Format(source))
+	}
+
+	toposort(statements, diagnostics)
+
+	if !diagnostics.HasErrors() {
+		t.Fatalf("expected a dependency-cycle diagnostic, got none")
+	}
+}
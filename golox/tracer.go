@@ -0,0 +1,99 @@
+package main
+
+// CallFrame records one live call for stack traces and "locals" inspection
+// while a Tracer is attached.
+type CallFrame struct {
+	Function    string
+	Line        uint
+	Environment *Environment
+}
+
+// Tracer is fired by the interpreter before and after every statement, so
+// a debugger (or profiler, or logger) can observe execution without the
+// interpreter knowing anything about it. It's a nil-checkable field on
+// Interpreter, so the cost when nothing is attached is a nil check before
+// and after each statement.
+type Tracer interface {
+	BeforeStatement(statement Statement, frames []CallFrame)
+	AfterStatement(statement Statement, frames []CallFrame)
+}
+
+func statementLine(statement Statement) uint {
+	switch s := statement.(type) {
+	case *ExpressionStatement:
+		return expressionLine(s.Expression)
+	case *PrintStatement:
+		return expressionLine(s.Expression)
+	case *ReturnStatement:
+		return s.Keyword.Line
+	case *VarStatement:
+		return s.Name.Line
+	case *IfStatement:
+		return expressionLine(s.Condition)
+	case *WhileStatement:
+		return expressionLine(s.Condition)
+	case *BreakStatement:
+		return s.Keyword.Line
+	case *ContinueStatement:
+		return s.Keyword.Line
+	case *ForeachStatement:
+		return s.Keyword.Line
+	}
+	return 0
+}
+
+func expressionLine(expression Expression) uint {
+	switch e := expression.(type) {
+	case *BinaryExpression:
+		return e.Operator.Line
+	case *VariableExpression:
+		return e.Name.Line
+	case *LiteralExpression:
+		return 0
+	case *CallExpression:
+		return e.Paren.Line
+	}
+	return 0
+}
+
+// statementFile mirrors statementLine, but returns the File the anchoring
+// token was scanned from (nil if there isn't one), so a breakpoint set as
+// "file:line" can be matched against the right file instead of colliding
+// with the same line number in another imported module.
+func statementFile(statement Statement) *File {
+	switch s := statement.(type) {
+	case *ExpressionStatement:
+		return expressionFile(s.Expression)
+	case *PrintStatement:
+		return expressionFile(s.Expression)
+	case *ReturnStatement:
+		return s.Keyword.File
+	case *VarStatement:
+		return s.Name.File
+	case *IfStatement:
+		return expressionFile(s.Condition)
+	case *WhileStatement:
+		return expressionFile(s.Condition)
+	case *BreakStatement:
+		return s.Keyword.File
+	case *ContinueStatement:
+		return s.Keyword.File
+	case *ForeachStatement:
+		return s.Keyword.File
+	}
+	return nil
+}
+
+func expressionFile(expression Expression) *File {
+	switch e := expression.(type) {
+	case *BinaryExpression:
+		return e.Operator.File
+	case *VariableExpression:
+		return e.Name.File
+	case *LiteralExpression:
+		return nil
+	case *CallExpression:
+		return e.Paren.File
+	}
+	return nil
+}
@@ -0,0 +1,393 @@
+package main
+
+import "fmt"
+
+// Static type names understood by the type checker. Anything else is
+// treated as an opaque class name, resolved against Classes.
+const (
+	TypeNumber = "number"
+	TypeString = "string"
+	TypeBool   = "bool"
+)
+
+type functionSignature struct {
+	arity      int
+	paramTypes []string
+	returnType string
+}
+
+type classSignature struct {
+	methods map[string]bool
+	fields  map[string]bool
+}
+
+// TypeChecker is an optional static analysis pass that runs after the
+// Resolver and before Interpreter.Interpret. It only checks what can be
+// proven from annotations and top-level declarations; anything without
+// an annotation is untyped and passes through uninspected, since Lox
+// otherwise remains a dynamically typed language.
+type TypeChecker struct {
+	Interpreter *Interpreter `json:"interpreter"`
+
+	Scopes Stack[map[string]string] `json:"scopes"`
+
+	Functions map[string]functionSignature `json:"-"`
+	Classes   map[string]classSignature    `json:"-"`
+
+	Debug bool `json:"debug"`
+}
+
+func NewTypeChecker(interpreter *Interpreter) TypeChecker {
+	return TypeChecker{
+		Interpreter: interpreter,
+		Scopes:      Stack[map[string]string]{},
+		Functions:   map[string]functionSignature{},
+		Classes:     map[string]classSignature{},
+		Debug:       interpreter.Debug,
+	}
+}
+
+func (t *TypeChecker) Check(statements []Statement) {
+	if t.Debug {
+		fmt.Println("Running type checker ...")
+	}
+
+	t.collectDeclarations(statements)
+
+	for _, statement := range statements {
+		t.checkStatement(statement)
+	}
+}
+
+// collectDeclarations gathers top-level function arity/param/return types
+// and class method/field names so call sites and property accesses can be
+// checked before (or regardless of) declaration order.
+func (t *TypeChecker) collectDeclarations(statements []Statement) {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *FunctionStatement:
+			t.Functions[s.Name.Lexeme] = functionSignature{
+				arity:      len(s.Params),
+				paramTypes: tokenNames(s.ParamTypes),
+				returnType: tokenName(s.ReturnType),
+			}
+		case *ClassStatement:
+			methods := map[string]bool{}
+			fields := map[string]bool{}
+			for _, method := range s.Methods {
+				methods[method.Name.Lexeme] = true
+				collectFields(method.Body, fields)
+			}
+			t.Classes[s.Name.Lexeme] = classSignature{methods: methods, fields: fields}
+		}
+	}
+}
+
+// collectFields walks a method body looking for `this.name = ...`
+// assignments, which is the only way Lox gives fields a declared shape.
+func collectFields(statements []Statement, fields map[string]bool) {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *ExpressionStatement:
+			collectFieldsFromExpression(s.Expression, fields)
+		case *BlockStatement:
+			collectFields(s.Statements, fields)
+		case *IfStatement:
+			collectFieldsFromExpression(s.Condition, fields)
+			collectFields([]Statement{s.Then}, fields)
+			if s.Else != nil {
+				collectFields([]Statement{s.Else}, fields)
+			}
+		case *WhileStatement:
+			collectFields([]Statement{s.Body}, fields)
+		}
+	}
+}
+
+func collectFieldsFromExpression(expression Expression, fields map[string]bool) {
+	if set, ok := expression.(*SetExpression); ok {
+		if _, ok := set.Object.(*ThisExpression); ok {
+			fields[set.Name.Lexeme] = true
+		}
+	}
+}
+
+func tokenName(token *Token) string {
+	if token == nil {
+		return ""
+	}
+	return token.Lexeme
+}
+
+func tokenNames(tokens []*Token) []string {
+	names := make([]string, len(tokens))
+	for idx, token := range tokens {
+		names[idx] = tokenName(token)
+	}
+	return names
+}
+
+func (t *TypeChecker) checkStatement(statement Statement) {
+	switch s := statement.(type) {
+	case *ExpressionStatement:
+		t.checkExpression(s.Expression)
+	case *PrintStatement:
+		t.checkExpression(s.Expression)
+	case *ReturnStatement:
+		if s.Value != nil {
+			t.checkExpression(s.Value)
+		}
+	case *VarStatement:
+		t.checkVarStatement(s)
+	case *BlockStatement:
+		t.beginScope()
+		for _, inner := range s.Statements {
+			t.checkStatement(inner)
+		}
+		t.endScope()
+	case *IfStatement:
+		t.checkExpression(s.Condition)
+		t.checkStatement(s.Then)
+		if s.Else != nil {
+			t.checkStatement(s.Else)
+		}
+	case *WhileStatement:
+		t.checkExpression(s.Condition)
+		t.checkStatement(s.Body)
+	case *ForeachStatement:
+		t.checkExpression(s.Iterable)
+		t.checkStatement(s.Body)
+	case *FunctionStatement:
+		t.checkFunction(s)
+	case *ClassStatement:
+		t.checkClass(s)
+	}
+}
+
+func (t *TypeChecker) checkVarStatement(statement *VarStatement) {
+	declared := tokenName(statement.Type)
+
+	if statement.Initializer != nil {
+		inferred := t.checkExpression(statement.Initializer)
+		if declared != "" && inferred != "" && declared != inferred {
+			t.Interpreter.Diagnostics.Add(PhaseTypeCheck, statement.Name, fmt.Sprintf("Can't assign %s to variable of type %s.", inferred, declared))
+		}
+	}
+
+	t.declare(statement.Name.Lexeme, declared)
+}
+
+func (t *TypeChecker) checkFunction(statement *FunctionStatement) {
+	t.beginScope()
+	for idx, param := range statement.Params {
+		t.declare(param.Lexeme, tokenName(statement.ParamTypes[idx]))
+	}
+
+	for _, body := range statement.Body {
+		t.checkStatement(body)
+	}
+	t.endScope()
+}
+
+func (t *TypeChecker) checkClass(statement *ClassStatement) {
+	if statement.Superclass != nil {
+		if _, ok := t.Classes[statement.Superclass.Name.Lexeme]; !ok {
+			t.Interpreter.Diagnostics.Add(PhaseTypeCheck, statement.Superclass.Name, "Superclass must be a class.")
+		}
+	}
+
+	for _, method := range statement.Methods {
+		t.checkFunction(method)
+	}
+}
+
+func (t *TypeChecker) beginScope() {
+	t.Scopes.Push(map[string]string{})
+}
+
+func (t *TypeChecker) endScope() {
+	t.Scopes.Pop()
+}
+
+func (t *TypeChecker) declare(name string, typeName string) {
+	if t.Scopes.IsEmpty() {
+		return
+	}
+	scope, _ := t.Scopes.Peek()
+	scope[name] = typeName
+}
+
+func (t *TypeChecker) lookup(name string) string {
+	for idx := t.Scopes.Size() - 1; idx >= 0; idx-- {
+		if typeName, ok := t.Scopes[idx][name]; ok {
+			return typeName
+		}
+	}
+	return ""
+}
+
+// isShadowed reports whether name is declared in some enclosing scope -
+// a param or local var - regardless of whether that declaration carries a
+// type annotation. lookup alone can't answer this: an untyped local
+// declares an entry whose typeName is "", indistinguishable from "not
+// declared anywhere" if all you check is the returned string.
+func (t *TypeChecker) isShadowed(name string) bool {
+	for idx := t.Scopes.Size() - 1; idx >= 0; idx-- {
+		if _, ok := t.Scopes[idx][name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExpression checks an expression and returns its statically known
+// type, or "" when the type can't be determined (which is not an error,
+// just an untyped result that further checks can't use).
+func (t *TypeChecker) checkExpression(expression Expression) string {
+	switch e := expression.(type) {
+	case *LiteralExpression:
+		switch e.Value.Type {
+		case LiteralTypeNumber:
+			return TypeNumber
+		case LiteralTypeString:
+			return TypeString
+		case LiteralTypeBool:
+			return TypeBool
+		}
+		return ""
+	case *VariableExpression:
+		return t.lookup(e.Name.Lexeme)
+	case *AssignExpression:
+		return t.checkExpression(e.Value)
+	case *GroupingExpression:
+		return t.checkExpression(e.Expression)
+	case *UnaryExpression:
+		operandType := t.checkExpression(e.Right)
+		if e.Operator.Type == Minus && operandType != "" && operandType != TypeNumber {
+			t.Interpreter.Diagnostics.Add(PhaseTypeCheck, e.Operator, "Operand must be a number.")
+		}
+		if e.Operator.Type == Minus {
+			return TypeNumber
+		}
+		return TypeBool
+	case *BinaryExpression:
+		return t.checkBinaryExpression(e)
+	case *LogicalExpression:
+		t.checkExpression(e.Left)
+		t.checkExpression(e.Right)
+		return TypeBool
+	case *TernaryExpression:
+		t.checkExpression(e.Condition)
+		trueType := t.checkExpression(e.True)
+		falseType := t.checkExpression(e.False)
+		if trueType == falseType {
+			return trueType
+		}
+		return ""
+	case *CallExpression:
+		return t.checkCallExpression(e)
+	case *GetExpression:
+		return t.checkGetExpression(e)
+	case *SetExpression:
+		t.checkExpression(e.Object)
+		return t.checkExpression(e.Value)
+	case *ListExpression:
+		for _, element := range e.Elements {
+			t.checkExpression(element)
+		}
+		return ""
+	case *MapExpression:
+		for idx, key := range e.Keys {
+			t.checkExpression(key)
+			t.checkExpression(e.Values[idx])
+		}
+		return ""
+	case *IndexExpression:
+		t.checkExpression(e.Object)
+		t.checkExpression(e.Index)
+		return ""
+	case *IndexSetExpression:
+		t.checkExpression(e.Object)
+		t.checkExpression(e.Index)
+		return t.checkExpression(e.Value)
+	}
+
+	return ""
+}
+
+func (t *TypeChecker) checkBinaryExpression(expression *BinaryExpression) string {
+	leftType := t.checkExpression(expression.Left)
+	rightType := t.checkExpression(expression.Right)
+
+	switch expression.Operator.Type {
+	case Plus:
+		if leftType != "" && rightType != "" {
+			if leftType == TypeString || rightType == TypeString {
+				return TypeString
+			}
+			if leftType != TypeNumber || rightType != TypeNumber {
+				t.Interpreter.Diagnostics.Add(PhaseTypeCheck, expression.Operator, "Operands must be two numbers or two strings.")
+			}
+		}
+		return ""
+	case Minus, Star, Slash:
+		if leftType != "" && leftType != TypeNumber {
+			t.Interpreter.Diagnostics.Add(PhaseTypeCheck, expression.Operator, "Operand must be a number.")
+		}
+		if rightType != "" && rightType != TypeNumber {
+			t.Interpreter.Diagnostics.Add(PhaseTypeCheck, expression.Operator, "Operand must be a number.")
+		}
+		return TypeNumber
+	case Greater, GreaterEqual, Less, LessEqual, EqualEqual, BangEqual:
+		return TypeBool
+	}
+
+	return ""
+}
+
+func (t *TypeChecker) checkCallExpression(expression *CallExpression) string {
+	for _, argument := range expression.Arguments {
+		t.checkExpression(argument)
+	}
+
+	variable, ok := expression.Callee.(*VariableExpression)
+	if !ok {
+		return ""
+	}
+
+	if t.isShadowed(variable.Name.Lexeme) {
+		// A param or local shadows this name, so it isn't necessarily the
+		// top-level function t.Functions knows about - checking arity
+		// against that signature would be checking the wrong callee.
+		return ""
+	}
+
+	signature, ok := t.Functions[variable.Name.Lexeme]
+	if !ok {
+		return ""
+	}
+
+	if len(expression.Arguments) != signature.arity {
+		t.Interpreter.Diagnostics.Add(PhaseTypeCheck, expression.Paren, fmt.Sprintf("Expected %d arguments but got %d.", signature.arity, len(expression.Arguments)))
+	}
+
+	return signature.returnType
+}
+
+func (t *TypeChecker) checkGetExpression(expression *GetExpression) string {
+	className := t.checkExpression(expression.Object)
+	if className == "" {
+		return ""
+	}
+
+	signature, ok := t.Classes[className]
+	if !ok {
+		return ""
+	}
+
+	if !signature.methods[expression.Name.Lexeme] && !signature.fields[expression.Name.Lexeme] {
+		t.Interpreter.Diagnostics.Add(PhaseTypeCheck, expression.Name, fmt.Sprintf("Undefined property '%s'.", expression.Name.Lexeme))
+	}
+
+	return ""
+}
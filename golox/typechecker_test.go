@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+func checkTypes(source string) *Diagnostics {
+	diagnostics := NewDiagnostics()
+	scanner := NewScanner(source, nil, diagnostics)
+	scanner.ScanTokens()
+
+	parser := NewParser(scanner.Tokens, false, diagnostics)
+	statements, _ := parser.Parse()
+
+	interpreter := NewInterpreter(false, nil)
+	interpreter.Diagnostics = diagnostics
+
+	resolver := NewResolver(&interpreter)
+	statements = resolver.Resolve(statements)
+
+	typeChecker := NewTypeChecker(&interpreter)
+	typeChecker.Check(statements)
+
+	return diagnostics
+}
+
+// TestCheckCallExpressionIgnoresShadowedFunction covers a local variable
+// that shadows a top-level function of the same name: the call should be
+// checked against whatever's actually in scope, not the top-level
+// signature, since the typechecker doesn't track value flow well enough
+// to know the local isn't itself a function.
+func TestCheckCallExpressionIgnoresShadowedFunction(t *testing.T) {
+	source := `
+fun twice(f) { return f; }
+
+fun run() {
+	var twice = clock;
+	twice();
+}
+`
+	diagnostics := checkTypes(source)
+	if diagnostics.HasErrors() {
+		t.Fatalf("expected no type-check diagnostics, got: %s", diagnostics.Format(source))
+	}
+}
+
+// TestCheckCallExpressionStillCatchesArityMismatch makes sure the
+// shadowing check didn't also blind the typechecker to a real arity
+// mismatch against an unshadowed top-level function.
+func TestCheckCallExpressionStillCatchesArityMismatch(t *testing.T) {
+	source := `
+fun twice(f) { return f; }
+twice();
+`
+	diagnostics := checkTypes(source)
+	if !diagnostics.HasErrors() {
+		t.Fatalf("expected an arity-mismatch diagnostic, got none")
+	}
+}
+
+// TestCheckVarStatementAnnotation covers the `var x: number = 1;` syntax
+// end to end - it can't even scan without the Question/Colon fix, so
+// nothing here was ever exercised before now.
+func TestCheckVarStatementAnnotation(t *testing.T) {
+	source := `var x: number = 1;`
+
+	diagnostics := checkTypes(source)
+	if diagnostics.HasErrors() {
+		t.Fatalf("expected no type-check diagnostics, got: %s", diagnostics.Format(source))
+	}
+}
+
+// TestCheckVarStatementAnnotationMismatch covers the diagnostic side of
+// the same syntax: a declared type that disagrees with the initializer.
+func TestCheckVarStatementAnnotationMismatch(t *testing.T) {
+	source := `var x: number = "1";`
+
+	diagnostics := checkTypes(source)
+	if !diagnostics.HasErrors() {
+		t.Fatalf("expected a type-mismatch diagnostic, got none")
+	}
+}
+
+// TestCheckFunctionParamAndReturnAnnotations covers
+// `fun add(a: number, b: number): number { ... }` - both the param types
+// feeding into the body's type checks and the declared return type.
+func TestCheckFunctionParamAndReturnAnnotations(t *testing.T) {
+	source := `
+fun add(a: number, b: number): number { return a + b; }
+var sum: number = add(1, 2);
+`
+	diagnostics := checkTypes(source)
+	if diagnostics.HasErrors() {
+		t.Fatalf("expected no type-check diagnostics, got: %s", diagnostics.Format(source))
+	}
+}
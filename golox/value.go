@@ -1,8 +1,8 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
 )
 
 type ValueType int
@@ -17,6 +17,13 @@ func (t ValueType) String() string {
 		"function",
 		"class",
 		"instance",
+		"module",
+		"list",
+		"map",
+		"closure",
+		"vm-class",
+		"vm-instance",
+		"vm-function-proto",
 	}[t]
 }
 
@@ -28,6 +35,19 @@ const (
 	ValueTypeFunction ValueType = 4
 	ValueTypeClass    ValueType = 5
 	ValueTypeInstance ValueType = 6
+	ValueTypeModule   ValueType = 7
+	ValueTypeList     ValueType = 8
+	ValueTypeMap      ValueType = 9
+
+	// The --backend=vm types below are produced and consumed only by
+	// Compiler/VM - ValueTypeFunction/ValueTypeClass/ValueTypeInstance
+	// stay tied to the tree-walk backend's Callable/LoxClass/LoxInstance,
+	// which don't have the shape (a flat Chunk, upvalues, stack slots)
+	// the VM needs.
+	ValueTypeClosure          ValueType = 10
+	ValueTypeBytecodeClass    ValueType = 11
+	ValueTypeBytecodeInstance ValueType = 12
+	ValueTypeBytecodeFunction ValueType = 13
 )
 
 type Value struct {
@@ -42,6 +62,22 @@ type Value struct {
 	ClassValue    Callable `json:"class"`
 
 	InstanceValue *LoxInstance `json:"instance"`
+
+	ModuleValue *Module `json:"module"`
+
+	ListValue *LoxList `json:"list"`
+	MapValue  *LoxMap  `json:"map"`
+
+	// --backend=vm-only storage - see the ValueTypeClosure/-BytecodeClass/
+	// -BytecodeInstance comment above.
+	ClosureValue          *BytecodeClosure  `json:"-"`
+	BytecodeClassValue    *BytecodeClass    `json:"-"`
+	BytecodeInstanceValue *BytecodeInstance `json:"-"`
+
+	// BytecodeFunctionValue holds an as-yet-uninstantiated compiled
+	// function, the constant-pool payload OpClosure turns into a
+	// ClosureValue by binding upvalues at runtime.
+	BytecodeFunctionValue *BytecodeFunction `json:"-"`
 }
 
 func (v Value) String() string {
@@ -73,9 +109,103 @@ func (v Value) String() string {
 		return v.InstanceValue.String()
 	}
 
-	fmt.Fprintf(os.Stderr, "Unsupported value type %v\n", v.Type)
-	os.Exit(1)
-	return ""
+	if v.Type == ValueTypeModule {
+		return v.ModuleValue.String()
+	}
+
+	if v.Type == ValueTypeList {
+		return v.ListValue.String()
+	}
+
+	if v.Type == ValueTypeMap {
+		return v.MapValue.String()
+	}
+
+	if v.Type == ValueTypeClosure {
+		return v.ClosureValue.String()
+	}
+
+	if v.Type == ValueTypeBytecodeClass {
+		return v.BytecodeClassValue.String()
+	}
+
+	if v.Type == ValueTypeBytecodeInstance {
+		return v.BytecodeInstanceValue.String()
+	}
+
+	if v.Type == ValueTypeBytecodeFunction {
+		return v.BytecodeFunctionValue.String()
+	}
+
+	// A host embedding the interpreter shouldn't have its whole process
+	// killed by a malformed Value, so report the problem in the string
+	// instead of calling os.Exit.
+	return fmt.Sprintf("<unsupported value type %v>", v.Type)
+}
+
+// valueJSON is the on-the-wire tagged union for Value: only the fields
+// relevant to Type are populated, instead of marshalling every variant's
+// storage like the struct tags on Value would do by default.
+type valueJSON struct {
+	Type ValueType `json:"type"`
+
+	Number float64           `json:"number,omitempty"`
+	String string            `json:"string,omitempty"`
+	Bool   bool              `json:"bool,omitempty"`
+	List   []*Value          `json:"list,omitempty"`
+	Map    map[string]*Value `json:"map,omitempty"`
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	out := valueJSON{Type: v.Type}
+
+	switch v.Type {
+	case ValueTypeNil:
+	case ValueTypeNumber:
+		out.Number = v.NumberValue
+	case ValueTypeString:
+		out.String = v.StringValue
+	case ValueTypeBool:
+		out.Bool = v.BoolValue
+	case ValueTypeList:
+		out.List = v.ListValue.Elements
+	case ValueTypeMap:
+		out.Map = v.MapValue.Entries
+	default:
+		// function/class/instance/module values point at *Environment and
+		// form cycles that need a stable identity scheme before they can
+		// round-trip - fail loudly rather than silently serializing a Value
+		// that comes back with a nil payload.
+		return nil, fmt.Errorf("value: %s values can't be serialized yet", v.Type)
+	}
+
+	return json.Marshal(out)
+}
+
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var in valueJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	v.Type = in.Type
+	switch in.Type {
+	case ValueTypeNil:
+	case ValueTypeNumber:
+		v.NumberValue = in.Number
+	case ValueTypeString:
+		v.StringValue = in.String
+	case ValueTypeBool:
+		v.BoolValue = in.Bool
+	case ValueTypeList:
+		v.ListValue = NewLoxList(in.List)
+	case ValueTypeMap:
+		v.MapValue = NewLoxMap(in.Map)
+	default:
+		return fmt.Errorf("value: %s values can't be deserialized", in.Type)
+	}
+
+	return nil
 }
 
 func (v *Value) isTruthy() bool {
@@ -117,6 +247,20 @@ func (v *Value) Equals(other *Value) bool {
 		return v.ClassValue == other.ClassValue
 	case ValueTypeInstance:
 		return v.InstanceValue == other.InstanceValue
+	case ValueTypeModule:
+		return v.ModuleValue == other.ModuleValue
+	case ValueTypeList:
+		return v.ListValue == other.ListValue
+	case ValueTypeMap:
+		return v.MapValue == other.MapValue
+	case ValueTypeClosure:
+		return v.ClosureValue == other.ClosureValue
+	case ValueTypeBytecodeClass:
+		return v.BytecodeClassValue == other.BytecodeClassValue
+	case ValueTypeBytecodeInstance:
+		return v.BytecodeInstanceValue == other.BytecodeInstanceValue
+	case ValueTypeBytecodeFunction:
+		return v.BytecodeFunctionValue == other.BytecodeFunctionValue
 	default:
 		return false
 	}
@@ -192,3 +336,52 @@ func NewInstanceValue(instance *LoxInstance) Value {
 		InstanceValue: instance,
 	}
 }
+
+func NewModuleValue(module *Module) Value {
+	return Value{
+		Type:        ValueTypeModule,
+		ModuleValue: module,
+	}
+}
+
+func NewListValue(list *LoxList) Value {
+	return Value{
+		Type:      ValueTypeList,
+		ListValue: list,
+	}
+}
+
+func NewMapValue(m *LoxMap) Value {
+	return Value{
+		Type:     ValueTypeMap,
+		MapValue: m,
+	}
+}
+
+func NewClosureValue(closure *BytecodeClosure) Value {
+	return Value{
+		Type:         ValueTypeClosure,
+		ClosureValue: closure,
+	}
+}
+
+func NewBytecodeClassValue(class *BytecodeClass) Value {
+	return Value{
+		Type:               ValueTypeBytecodeClass,
+		BytecodeClassValue: class,
+	}
+}
+
+func NewBytecodeInstanceValue(instance *BytecodeInstance) Value {
+	return Value{
+		Type:                  ValueTypeBytecodeInstance,
+		BytecodeInstanceValue: instance,
+	}
+}
+
+func NewBytecodeFunctionValue(function *BytecodeFunction) Value {
+	return Value{
+		Type:                  ValueTypeBytecodeFunction,
+		BytecodeFunctionValue: function,
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import "fmt"
+
+const maxFrames = 255
+
+// stackMax is the value stack's preallocated capacity: every frame can
+// address 256 local slots. Keeping the stack from reallocating keeps an
+// open Upvalue's Location pointing at the live slot.
+const stackMax = maxFrames * 256
+
+// BytecodeClosure pairs a compiled BytecodeFunction with the upvalues its
+// particular instantiation captured - the runtime counterpart to
+// LoxFunction.Closure, but a flat slice of Upvalue pointers instead of a
+// captured *Environment chain.
+type BytecodeClosure struct {
+	Function *BytecodeFunction
+	Upvalues []*Upvalue
+}
+
+func (c *BytecodeClosure) String() string {
+	return c.Function.String()
+}
+
+// Upvalue is a closed-over variable. While the stack frame that owns it is
+// still live, Location points directly at that frame's stack slot, so
+// reads/writes through the upvalue and through the original local stay in
+// sync. Once the frame returns, close copies the value into Closed and
+// repoints Location there, so the closure keeps working after its stack
+// slot is gone.
+type Upvalue struct {
+	Location *Value
+	Closed   Value
+}
+
+func (u *Upvalue) close() {
+	u.Closed = *u.Location
+	u.Location = &u.Closed
+}
+
+// BytecodeInstance is the VM backend's counterpart to LoxInstance.
+type BytecodeInstance struct {
+	Class  *BytecodeClass
+	Fields map[string]Value
+}
+
+func (i *BytecodeInstance) String() string {
+	return fmt.Sprintf("%s instance", i.Class.Name())
+}
+
+// vmFrame is one call's bookkeeping: which closure it's executing, where
+// its instruction pointer is, and where its locals start on the shared
+// value stack. Named vmFrame (not CallFrame) to stay distinct from the
+// tree-walk backend's CallFrame in tracer.go, which a Tracer/DebugServer
+// walks instead of this one.
+type vmFrame struct {
+	closure   *BytecodeClosure
+	ip        int
+	stackBase int
+}
+
+// VM is the --backend=vm execution engine: an explicit value stack and
+// call-frame slice instead of the tree-walk backend's Go call stack and
+// chained *Environment. Globals are still looked up through an
+// *Environment, shared in spirit with the tree-walk backend (both back
+// onto the same stdlib), and native functions are bridged through the
+// embedded *Interpreter, since Callable.Call's signature is shared by
+// both backends.
+type VM struct {
+	interpreter *Interpreter
+
+	stack  []Value
+	frames []vmFrame
+
+	openUpvalues []*openUpvalue
+}
+
+type openUpvalue struct {
+	slot    int
+	upvalue *Upvalue
+}
+
+func NewVM(interpreter *Interpreter) *VM {
+	return &VM{interpreter: interpreter, stack: make([]Value, 0, stackMax)}
+}
+
+// Interpret runs function (the result of Compile) to completion, returning
+// the value of its implicit top-level OpReturn.
+func (vm *VM) Interpret(function *BytecodeFunction) (*Value, error) {
+	closure := &BytecodeClosure{Function: function}
+
+	vm.push(NewClosureValue(closure))
+	if err := vm.call(closure, 0); err != nil {
+		return nil, err
+	}
+
+	return vm.run()
+}
+
+func (vm *VM) push(value Value) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() Value {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *VM) peek(distance int) *Value {
+	return &vm.stack[len(vm.stack)-1-distance]
+}
+
+func (vm *VM) currentFrame() *vmFrame {
+	return &vm.frames[len(vm.frames)-1]
+}
+
+func (vm *VM) runtimeError(message string) error {
+	line := uint(0)
+	if len(vm.frames) > 0 {
+		frame := vm.currentFrame()
+		line = frame.closure.Function.Chunk.Lines[frame.ip-1]
+	}
+	return &RuntimeError{Message: message, Token: &Token{Line: line}}
+}
+
+func (vm *VM) call(closure *BytecodeClosure, argCount int) error {
+	if argCount != closure.Function.Arity {
+		return vm.runtimeError(fmt.Sprintf("Expected %d arguments but got %d.", closure.Function.Arity, argCount))
+	}
+
+	if len(vm.frames) >= maxFrames {
+		return vm.runtimeError("Stack overflow.")
+	}
+
+	vm.frames = append(vm.frames, vmFrame{
+		closure:   closure,
+		stackBase: len(vm.stack) - argCount - 1,
+	})
+	return nil
+}
+
+func (vm *VM) callValue(callee Value, argCount int) error {
+	switch callee.Type {
+	case ValueTypeClosure:
+		return vm.call(callee.ClosureValue, argCount)
+
+	case ValueTypeBytecodeClass:
+		instance := &BytecodeInstance{Class: callee.BytecodeClassValue, Fields: map[string]Value{}}
+		vm.stack[len(vm.stack)-argCount-1] = NewBytecodeInstanceValue(instance)
+
+		if initializer, ok := callee.BytecodeClassValue.Methods["init"]; ok {
+			return vm.call(initializer, argCount)
+		}
+		if argCount != 0 {
+			return vm.runtimeError(fmt.Sprintf("Expected 0 arguments but got %d.", argCount))
+		}
+		return nil
+
+	case ValueTypeFunction, ValueTypeClass:
+		// bridge into the tree-walk backend's Callable for native/stdlib
+		// functions, which the VM shares through the same Globals
+		callable := callee.FunctionValue
+		if callable == nil {
+			callable = callee.ClassValue
+		}
+
+		arguments := make([]*Value, argCount)
+		for idx := 0; idx < argCount; idx++ {
+			v := vm.stack[len(vm.stack)-argCount+idx]
+			arguments[idx] = &v
+		}
+
+		result, err := callable.Call(vm.interpreter, arguments)
+		if err != nil {
+			return err
+		}
+
+		vm.stack = vm.stack[:len(vm.stack)-argCount-1]
+		if result != nil {
+			vm.push(*result)
+		} else {
+			vm.push(NewNilValue())
+		}
+		return nil
+
+	default:
+		return vm.runtimeError("Can only call functions and classes.")
+	}
+}
+
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	for _, open := range vm.openUpvalues {
+		if open.slot == slot {
+			return open.upvalue
+		}
+	}
+
+	upvalue := &Upvalue{Location: &vm.stack[slot]}
+	vm.openUpvalues = append(vm.openUpvalues, &openUpvalue{slot: slot, upvalue: upvalue})
+	return upvalue
+}
+
+// closeUpvalues closes every open upvalue pointing at stack slot lastSlot
+// or higher, called as a frame's locals (at lastSlot and above) go out of
+// scope - either OpCloseUpvalue for a block, or a return popping the
+// whole frame.
+func (vm *VM) closeUpvalues(lastSlot int) {
+	kept := vm.openUpvalues[:0]
+	for _, open := range vm.openUpvalues {
+		if open.slot >= lastSlot {
+			open.upvalue.close()
+		} else {
+			kept = append(kept, open)
+		}
+	}
+	vm.openUpvalues = kept
+}
+
+func (vm *VM) run() (*Value, error) {
+	frame := vm.currentFrame()
+	chunk := frame.closure.Function.Chunk
+
+	readByte := func() byte {
+		b := chunk.Code[frame.ip]
+		frame.ip++
+		return b
+	}
+	readUint16 := func() uint16 {
+		value := chunk.ReadUint16(frame.ip)
+		frame.ip += 2
+		return value
+	}
+	readConstant := func() Value {
+		return chunk.Constants[readByte()]
+	}
+
+	for {
+		op := OpCode(readByte())
+
+		switch op {
+		case OpConstant:
+			vm.push(readConstant())
+
+		case OpNil:
+			vm.push(NewNilValue())
+		case OpTrue:
+			vm.push(NewBoolValue(true))
+		case OpFalse:
+			vm.push(NewBoolValue(false))
+		case OpPop:
+			vm.pop()
+
+		case OpGetLocal:
+			slot := frame.stackBase + int(readByte())
+			vm.push(vm.stack[slot])
+		case OpSetLocal:
+			slot := frame.stackBase + int(readByte())
+			vm.stack[slot] = *vm.peek(0)
+
+		case OpGetGlobal:
+			name := readConstant().StringValue
+			value, err := vm.interpreter.Globals.Get(&Token{Lexeme: name})
+			if err != nil {
+				return nil, vm.runtimeError(fmt.Sprintf("Undefined variable '%s'.", name))
+			}
+			vm.push(*value)
+		case OpDefineGlobal:
+			name := readConstant().StringValue
+			value := vm.pop()
+			vm.interpreter.Globals.Define(name, &value)
+		case OpSetGlobal:
+			name := readConstant().StringValue
+			value := *vm.peek(0)
+			if err := vm.interpreter.Globals.Assign(&Token{Lexeme: name}, &value); err != nil {
+				return nil, err
+			}
+
+		case OpGetUpvalue:
+			index := readByte()
+			vm.push(*frame.closure.Upvalues[index].Location)
+		case OpSetUpvalue:
+			index := readByte()
+			*frame.closure.Upvalues[index].Location = *vm.peek(0)
+
+		case OpGetProperty:
+			name := readConstant().StringValue
+			instance := vm.peek(0)
+			if instance.Type != ValueTypeBytecodeInstance {
+				return nil, vm.runtimeError("Only instances have properties.")
+			}
+
+			if value, ok := instance.BytecodeInstanceValue.Fields[name]; ok {
+				vm.pop()
+				vm.push(value)
+				break
+			}
+
+			if method, ok := instance.BytecodeInstanceValue.Class.Methods[name]; ok {
+				vm.pop()
+				vm.push(NewClosureValue(method))
+				break
+			}
+
+			return nil, vm.runtimeError(fmt.Sprintf("Undefined property '%s'.", name))
+
+		case OpSetProperty:
+			name := readConstant().StringValue
+			value := vm.pop()
+			instance := vm.pop()
+			if instance.Type != ValueTypeBytecodeInstance {
+				return nil, vm.runtimeError("Only instances have fields.")
+			}
+			instance.BytecodeInstanceValue.Fields[name] = value
+			vm.push(value)
+
+		case OpEqual:
+			right := vm.pop()
+			left := vm.pop()
+			vm.push(NewBoolValue(left.Equals(&right)))
+		case OpGreater:
+			if err := vm.binaryNumberOp(func(a, b float64) Value { return NewBoolValue(a > b) }); err != nil {
+				return nil, err
+			}
+		case OpLess:
+			if err := vm.binaryNumberOp(func(a, b float64) Value { return NewBoolValue(a < b) }); err != nil {
+				return nil, err
+			}
+
+		case OpAdd:
+			right := vm.pop()
+			left := vm.pop()
+			if left.Type == ValueTypeNumber && right.Type == ValueTypeNumber {
+				vm.push(NewNumberValue(left.NumberValue + right.NumberValue))
+			} else if left.Type == ValueTypeString || right.Type == ValueTypeString {
+				vm.push(NewStringValue(left.String() + right.String()))
+			} else {
+				return nil, vm.runtimeError("Operands must be two numbers or two strings.")
+			}
+		case OpSubtract:
+			if err := vm.binaryNumberOp(func(a, b float64) Value { return NewNumberValue(a - b) }); err != nil {
+				return nil, err
+			}
+		case OpMultiply:
+			if err := vm.binaryNumberOp(func(a, b float64) Value { return NewNumberValue(a * b) }); err != nil {
+				return nil, err
+			}
+		case OpDivide:
+			right := vm.peek(0)
+			if right.Type == ValueTypeNumber && right.NumberValue == 0 {
+				return nil, vm.runtimeError("Illegal divide by zero.")
+			}
+			if err := vm.binaryNumberOp(func(a, b float64) Value { return NewNumberValue(a / b) }); err != nil {
+				return nil, err
+			}
+
+		case OpNot:
+			value := vm.pop()
+			vm.push(NewBoolValue(!value.isTruthy()))
+		case OpNegate:
+			value := vm.pop()
+			if value.Type != ValueTypeNumber {
+				return nil, vm.runtimeError("Operand must be a number.")
+			}
+			vm.push(NewNumberValue(-value.NumberValue))
+
+		case OpPrint:
+			fmt.Fprintln(vm.interpreter.Output, vm.pop().String())
+
+		case OpJump:
+			offset := readUint16()
+			frame.ip += int(offset)
+		case OpJumpIfFalse:
+			offset := readUint16()
+			if !vm.peek(0).isTruthy() {
+				frame.ip += int(offset)
+			}
+		case OpLoop:
+			offset := readUint16()
+			frame.ip -= int(offset)
+
+		case OpCall:
+			argCount := int(readByte())
+			if err := vm.callValue(*vm.peek(argCount), argCount); err != nil {
+				return nil, err
+			}
+			frame = vm.currentFrame()
+			chunk = frame.closure.Function.Chunk
+
+		case OpClosure:
+			function := readConstant().BytecodeFunctionValue
+			closure := &BytecodeClosure{Function: function, Upvalues: make([]*Upvalue, function.UpvalueCount)}
+
+			for idx := 0; idx < function.UpvalueCount; idx++ {
+				isLocal := readByte()
+				index := readByte()
+				if isLocal == 1 {
+					closure.Upvalues[idx] = vm.captureUpvalue(frame.stackBase + int(index))
+				} else {
+					closure.Upvalues[idx] = frame.closure.Upvalues[index]
+				}
+			}
+
+			vm.push(NewClosureValue(closure))
+
+		case OpCloseUpvalue:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.pop()
+
+		case OpClass:
+			name := readConstant().StringValue
+			vm.push(NewBytecodeClassValue(&BytecodeClass{ClassName: name, Methods: map[string]*BytecodeClosure{}}))
+
+		case OpMethod:
+			name := readConstant().StringValue
+			method := vm.pop().ClosureValue
+			class := vm.peek(0).BytecodeClassValue
+			class.Methods[name] = method
+
+		case OpReturn:
+			result := vm.pop()
+
+			vm.closeUpvalues(frame.stackBase)
+			vm.stack = vm.stack[:frame.stackBase]
+			vm.frames = vm.frames[:len(vm.frames)-1]
+
+			if len(vm.frames) == 0 {
+				return &result, nil
+			}
+
+			vm.push(result)
+			frame = vm.currentFrame()
+			chunk = frame.closure.Function.Chunk
+
+		default:
+			return nil, vm.runtimeError(fmt.Sprintf("Unknown opcode %v", op))
+		}
+	}
+}
+
+func (vm *VM) binaryNumberOp(op func(a, b float64) Value) error {
+	right := vm.pop()
+	left := vm.pop()
+
+	if left.Type != ValueTypeNumber || right.Type != ValueTypeNumber {
+		return vm.runtimeError("Operands must be numbers.")
+	}
+
+	vm.push(op(left.NumberValue, right.NumberValue))
+	return nil
+}